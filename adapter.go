@@ -0,0 +1,53 @@
+package imacd
+
+// AsSeriesCalculator adapts the indicator to the Calculate([]float64)
+// []float64 shape common to Go TA libraries. Each call runs the closes
+// through a fresh indicator with the same configuration and returns the
+// histogram (SH) per close; the receiver itself is not updated. Indicators
+// built from custom moving averages fall back to the default ones.
+//
+// The series form is close-only: every bar is fed as high = low = close, so
+// both bands collapse onto the smoothed close and the channel has no width.
+// MD then measures the mid line against that single line, which differs from
+// the result on real high/low data. Use BatchUpdate when bars are available.
+func (im *ImpulseMACD) AsSeriesCalculator() func([]float64) []float64 {
+	cfg := im.Config()
+	return func(closes []float64) []float64 {
+		calc := newFromConfigOrDefault(cfg, im)
+		out := make([]float64, len(closes))
+		for i, c := range closes {
+			out[i] = calc.Update(c, c, c).SH
+		}
+		return out
+	}
+}
+
+// newFromConfigOrDefault creates a fresh indicator from cfg. Lines cfg names
+// as custom fall back to the default moving averages and missing lengths to
+// the default lengths, while every other option in cfg still applies. The MD
+// transform and clock, which a Config cannot describe, are taken from like.
+func newFromConfigOrDefault(cfg Config, like *ImpulseMACD) *ImpulseMACD {
+	if cfg.BandMA == MACustom {
+		cfg.BandMA = ""
+	}
+	if cfg.MidMA == MACustom {
+		cfg.MidMA = ""
+	}
+	if cfg.SignalMA == MACustom {
+		cfg.SignalMA = ""
+	}
+	if cfg.LengthMA < 1 {
+		cfg.LengthMA = DefaultLengthMA
+	}
+	if cfg.LengthSignal < 1 {
+		cfg.LengthSignal = DefaultLengthSignal
+	}
+	im, err := NewFromConfig(cfg)
+	if err != nil {
+		// Only reachable for configurations no indicator reports
+		im = NewImpulseMACD(cfg.LengthMA, cfg.LengthSignal)
+	}
+	im.mdTransform = like.mdTransform
+	im.clock = like.clock
+	return im
+}
@@ -0,0 +1,75 @@
+package imacd
+
+import (
+	"fmt"
+	"math"
+)
+
+// Aggregate combines consecutive sub-bars into one bar: the first open, the
+// highest high, the lowest low, the last close, quotes and source, and the
+// first bar's time. It returns ErrEmptyInput for no bars.
+func Aggregate(sub []PriceBar) (PriceBar, error) {
+	if len(sub) == 0 {
+		return PriceBar{}, ErrEmptyInput
+	}
+
+	bar := PriceBar{
+		Open: sub[0].Open,
+		High: math.Inf(-1),
+		Low:  math.Inf(1),
+		Time: sub[0].Time,
+	}
+	for _, s := range sub {
+		bar.High = math.Max(bar.High, s.High)
+		bar.Low = math.Min(bar.Low, s.Low)
+	}
+	last := sub[len(sub)-1]
+	bar.Close, bar.Bid, bar.Ask, bar.Source = last.Close, last.Bid, last.Ask, last.Source
+	return bar, nil
+}
+
+// UpdateAggregate processes the sub-bars of one higher timeframe bar with a
+// single update on their Aggregate
+func (im *ImpulseMACD) UpdateAggregate(sub []PriceBar) (ImpulseValue, error) {
+	bar, err := Aggregate(sub)
+	if err != nil {
+		return ImpulseValue{}, err
+	}
+	return im.updateBar(bar), nil
+}
+
+// Resample groups consecutive bars by factor and aggregates each group, so
+// factor 3 turns 5 minute bars into 15 minute ones. Groups start at the
+// first bar rather than at clock boundaries; a trailing partial group still
+// forms a bar, as the forming bar of the higher timeframe.
+func Resample(bars []PriceBar, factor int) ([]PriceBar, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("imacd: resample factor must be positive, got %d", factor)
+	}
+	resampled := make([]PriceBar, 0, (len(bars)+factor-1)/factor)
+	for start := 0; start < len(bars); start += factor {
+		bar, _ := Aggregate(bars[start:min(start+factor, len(bars))])
+		resampled = append(resampled, bar)
+	}
+	return resampled, nil
+}
+
+// MultiTimeframe resamples the base bars by each factor and computes the
+// indicator with the given lengths on every timeframe, keyed by factor.
+// Factor 1 is the base timeframe itself. Values of a timeframe are aligned
+// with its resampled bars, so index i of factor f covers base bars
+// [i*f, (i+1)*f).
+func MultiTimeframe(bars []PriceBar, factors []int, lengthMA, lengthSignal int) (map[int][]ImpulseValue, error) {
+	cfg := Config{LengthMA: lengthMA, LengthSignal: lengthSignal}
+	results := make(map[int][]ImpulseValue, len(factors))
+	for _, factor := range factors {
+		resampled, err := Resample(bars, factor)
+		if err != nil {
+			return nil, err
+		}
+		if results[factor], err = Compute(resampled, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,33 @@
+package imacd
+
+// HistogramArea returns the signed sum of the histogram from fromIndex to the
+// latest value, inclusive. Positive areas measure bullish momentum and
+// negative ones bearish. fromIndex is clamped to the calculated values.
+func (im *ImpulseMACD) HistogramArea(fromIndex int) float64 {
+	area := 0.0
+	for _, v := range im.values[min(max(fromIndex, 0), len(im.values)):] {
+		area += v.SH
+	}
+	return area
+}
+
+// AreaSinceLastZeroCross returns the signed histogram area of the current
+// leg: the bars since the histogram last crossed or left zero, all sharing
+// the latest bar's sign. It is 0 when the latest histogram value is zero.
+func (im *ImpulseMACD) AreaSinceLastZeroCross() float64 {
+	n := len(im.values)
+	if n == 0 || im.values[n-1].SH == 0 {
+		return 0
+	}
+
+	positive := im.values[n-1].SH > 0
+	start := n - 1
+	for start > 0 {
+		sh := im.values[start-1].SH
+		if sh == 0 || (sh > 0) != positive {
+			break
+		}
+		start--
+	}
+	return im.HistogramArea(start)
+}
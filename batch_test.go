@@ -0,0 +1,167 @@
+package imacd
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// TestStreamingMatchesBatch pins the contract that BatchUpdate produces
+// exactly what updating bar by bar does, for random bars and every option
+// path, so an option added to one path but not the other is caught
+func TestStreamingMatchesBatch(t *testing.T) {
+	optionSets := map[string][]Option{
+		"default":        nil,
+		"tv_rma":         {WithTradingViewRMA()},
+		"smoothing":      {WithHistogramSmoothing(4)},
+		"zscore":         {WithSHZScore(6)},
+		"graded":         {WithGradedColor()},
+		"percent_md":     {WithPercentMD()},
+		"log_price":      {WithLogPrice()},
+		"min_max":        {WithPersistentMinMax(12)},
+		"boost":          {WithExtremeBoost(2, 0.5)},
+		"weights":        {WithPriceWeights(1, 1, 1, 2)},
+		"neutral_band":   {WithNeutralBand(0.1)},
+		"precision":      {WithOutputPrecision(3)},
+		"debounce":       {WithCrossDebounce(2)},
+		"multi_ema":      {WithMidDepth(3)},
+		"max_history":    {WithMaxHistory(10)},
+		"band_length":    {WithBandLength(13)},
+		"color_close":    {WithColorSource(ColorFromClose), WithColorEpsilon(0.01)},
+		"explain":        {WithExplain(true)},
+		"no_histogram":   {WithoutHistogram()},
+		"sample_stats":   {WithSampleStatistics(true), WithSHZScore(5)},
+		"md_transform":   {WithMDTransform(math.Tanh)},
+		"time_decay":     {WithTimeDecay(90 * time.Second)},
+		"retain_series":  {WithRetainSources(), WithRetainBands(), WithRetainInputs()},
+		"timestamp_rule": {WithTimestampPolicy(TimestampReject)},
+	}
+
+	for seed := range uint64(3) {
+		bars := randomBars(rand.New(rand.NewPCG(seed, 183)), 300)
+		for name, opts := range optionSets {
+			t.Run(fmt.Sprintf("%s/seed%d", name, seed), func(t *testing.T) {
+				for _, timed := range []bool{false, true} {
+					input := bars
+					if !timed {
+						input = make([]PriceBar, len(bars))
+						for i, bar := range bars {
+							// UpdateOHLC takes the open, so let it differ here
+							bar.Open, bar.Time = bar.Low+(bar.High-bar.Low)/3, time.Time{}
+							input[i] = bar
+						}
+					}
+					checkStreamingMatchesBatch(t, input, timed, opts)
+				}
+			})
+		}
+	}
+}
+
+func checkStreamingMatchesBatch(t *testing.T, bars []PriceBar, timed bool, opts []Option) {
+	t.Helper()
+	stream, err := NewImpulseMACDWithOptions(8, 5, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := NewImpulseMACDWithOptions(8, 5, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := batch.BatchUpdate(bars)
+	for i, bar := range bars {
+		var want ImpulseValue
+		if timed {
+			want, err = stream.UpdateAt(bar.Time, bar.High, bar.Low, bar.Close)
+			if err != nil {
+				t.Fatalf("timed bar %d: %v", i, err)
+			}
+		} else {
+			want = stream.UpdateOHLC(bar.Open, bar.High, bar.Low, bar.Close)
+		}
+		if got[i] != want {
+			t.Fatalf("timed=%t bar %d: batch %+v, streaming %+v", timed, i, got[i], want)
+		}
+	}
+	if !batch.Equal(stream) {
+		t.Fatalf("timed=%t: batch and streaming indicators differ after %d bars", timed, len(bars))
+	}
+}
+
+// randomBars returns a positive random walk of n bars a minute apart, with
+// the open equal to the close as UpdateAt takes no open
+func randomBars(rng *rand.Rand, n int) []PriceBar {
+	bars := make([]PriceBar, n)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := 100.0
+	for i := range bars {
+		price *= 1 + rng.NormFloat64()*0.02
+		bars[i] = PriceBar{
+			Open:  price,
+			High:  price * (1 + rng.Float64()*0.01),
+			Low:   price * (1 - rng.Float64()*0.01),
+			Close: price,
+			Time:  start.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return bars
+}
+
+func TestBatchUpdateHLC(t *testing.T) {
+	bars := randomBars(rand.New(rand.NewPCG(10, 132)), 40)
+	highs, lows, closes := make([]float64, len(bars)), make([]float64, len(bars)), make([]float64, len(bars))
+	for i, bar := range bars {
+		highs[i], lows[i], closes[i] = bar.High, bar.Low, bar.Close
+	}
+
+	im := NewImpulseMACD(8, 5)
+	got, err := im.BatchUpdateHLC(highs, lows, closes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewImpulseMACD(8, 5)
+	for i, bar := range bars {
+		if v := want.Update(bar.High, bar.Low, bar.Close); got[i] != v {
+			t.Fatalf("bar %d: %+v, want %+v", i, got[i], v)
+		}
+	}
+
+	// Empty columns return an empty, non-nil result like BatchUpdate
+	for _, empty := range [][]float64{nil, {}} {
+		got, err := im.BatchUpdateHLC(empty, empty, empty)
+		if err != nil || got == nil || len(got) != 0 {
+			t.Fatalf("empty columns: %v, %v, want an empty result", got, err)
+		}
+	}
+	if empty := im.BatchUpdate(nil); empty == nil || len(empty) != 0 {
+		t.Fatalf("BatchUpdate(nil) = %v, want an empty result", empty)
+	}
+	if !im.Equal(want) {
+		t.Fatal("empty batches changed the state")
+	}
+}
+
+func TestBatchUpdateHLCLengthMismatch(t *testing.T) {
+	cols := []float64{11, 12, 13}
+	for _, c := range []struct{ highs, lows, closes []float64 }{
+		{cols, cols[:2], cols},
+		{cols, cols, cols[:1]},
+		{nil, cols, cols},
+		{cols[:2], cols, cols[:2]},
+	} {
+		im := NewImpulseMACD(8, 5)
+		im.Update(10, 9, 9.5)
+		got, err := im.BatchUpdateHLC(c.highs, c.lows, c.closes)
+		if err == nil || got != nil {
+			t.Fatalf("lengths %d/%d/%d: %v, %v, want an error", len(c.highs), len(c.lows), len(c.closes), got, err)
+		}
+		want := NewImpulseMACD(8, 5)
+		want.Update(10, 9, 9.5)
+		if !im.Equal(want) {
+			t.Fatalf("lengths %d/%d/%d: the rejected batch changed the state", len(c.highs), len(c.lows), len(c.closes))
+		}
+	}
+}
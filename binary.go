@@ -0,0 +1,405 @@
+package imacd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Binary format layout, all integers little-endian:
+//
+//	magic        byte    binaryMagic
+//	version      byte    binaryVersion
+//	lengthMA     uint32
+//	lengthSignal uint32
+//	count        uint64
+//	smmaHigh     SMMA    value float64, isInit byte
+//	smmaLow      SMMA
+//	zlema        ZLEMA   ema1 EMA, ema2 EMA, value float64
+//	signal       SMA     n uint32, n x float64, sum float64, value float64
+//	values       uint64  then MD, SB, SH float64 and color byte per value
+//	minMax       byte    1 when the MD range follows, 0 otherwise (version 2)
+//	  window     uint32
+//	  min, max   float64
+//	  seen       byte
+//	  n          uint32  then n x float64, the MD values in the window
+//	lastTime     time    the latest bar timestamp (version 3)
+//	timestamps   time    one per value
+//	sources      uint32  n, then n x float64, 0 without WithRetainSources
+//	bands        uint32  n, then n x high, low, mid float64
+//	inputs       uint32  n, then n x time, high, low, close, source float64
+//
+// where EMA is encoded as value float64, isInit byte, and time as a byte, 1
+// when set, followed by int64 Unix nanoseconds when set. Only the core
+// calculation, the persisted MD range, the timestamps and the retained
+// series are encoded: options are not, so restore into an indicator
+// configured the same way, and other optional outputs restart from the next
+// bar. Version 1 and 2 data, which lack the later fields, is still accepted.
+const (
+	binaryMagic   byte = 0x49 // 'I'
+	binaryVersion byte = 3
+)
+
+var colorCodes = []Color{"", ColorLime, ColorGreen, ColorRed, ColorOrange}
+
+// MarshalBinary encodes the lengths, all sub-indicator state and the
+// calculated values into a compact versioned binary form
+func (im *ImpulseMACD) MarshalBinary() ([]byte, error) {
+	high, low, mid, sma, ok := im.builtins()
+	if !ok {
+		return nil, ErrCustomMovingAverage
+	}
+	if im.lengthMA < 1 || im.lengthSignal < 1 {
+		return nil, fmt.Errorf("imacd: cannot encode lengths (%d, %d)", im.lengthMA, im.lengthSignal)
+	}
+
+	buf := make([]byte, 0, 64+8*sma.window.Len()+25*len(im.values))
+	buf = append(buf, binaryMagic, binaryVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(im.lengthMA))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(im.lengthSignal))
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(im.count))
+
+	buf = appendFloat(buf, high.value)
+	buf = appendBool(buf, high.isInit)
+	buf = appendFloat(buf, low.value)
+	buf = appendBool(buf, low.isInit)
+	buf = appendFloat(buf, mid.ema1.value)
+	buf = appendBool(buf, mid.ema1.isInit)
+	buf = appendFloat(buf, mid.ema2.value)
+	buf = appendBool(buf, mid.ema2.isInit)
+	buf = appendFloat(buf, mid.value)
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(sma.window.Len()))
+	for _, v := range sma.window.All() {
+		buf = appendFloat(buf, v)
+	}
+	buf = appendFloat(buf, sma.sum)
+	buf = appendFloat(buf, sma.value)
+
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(im.values)))
+	for _, v := range im.values {
+		code, err := colorCode(v.Color)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendFloat(buf, v.MD)
+		buf = appendFloat(buf, v.SB)
+		buf = appendFloat(buf, v.SH)
+		buf = append(buf, code)
+	}
+
+	if s := im.minMax; s != nil {
+		buf = append(buf, 1)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(s.window))
+		buf = appendFloat(buf, s.min)
+		buf = appendFloat(buf, s.max)
+		buf = appendBool(buf, s.seen)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s.values)))
+		for _, v := range s.values {
+			buf = appendFloat(buf, v)
+		}
+	} else {
+		buf = append(buf, 0)
+	}
+
+	buf = appendTime(buf, im.lastTime)
+	for _, v := range im.values {
+		buf = appendTime(buf, v.Timestamp)
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(im.sources)))
+	for _, v := range im.sources {
+		buf = appendFloat(buf, v)
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(im.bands)))
+	for _, b := range im.bands {
+		buf = appendFloat(buf, b.High)
+		buf = appendFloat(buf, b.Low)
+		buf = appendFloat(buf, b.Mid)
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(im.inputs)))
+	for _, in := range im.inputs {
+		buf = appendTime(buf, in.t)
+		for _, f := range [...]float64{in.high, in.low, in.close, in.src} {
+			buf = appendFloat(buf, f)
+		}
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary restores an indicator encoded by MarshalBinary, replacing
+// its lengths, sub-indicators, calculated values, timestamps and the sources,
+// bands and inputs it retains. The retained series stay aligned with the
+// values: when the data lacks a series the indicator retains, as version 1
+// and 2 data does, the values are dropped along with it. All other state is
+// cleared as by ResetState: the histogram smoothing, cross tracking, the
+// forming value and active checkpoints, so with TimestampMerge the next bar
+// cannot be merged into the restored one. Options and handlers are kept.
+// Timestamps come back in UTC, and histogram colors are recomputed from the
+// restored histogram, the oldest value's against zero. Like MarshalBinary,
+// it returns ErrCustomMovingAverage unless the receiver uses the default
+// moving averages: RMA bands, a MultiEMA mid line and custom averages are
+// rejected rather than replaced.
+func (im *ImpulseMACD) UnmarshalBinary(data []byte) error {
+	im.checkReentry()
+	if _, _, _, _, ok := im.builtins(); !ok {
+		return ErrCustomMovingAverage
+	}
+	r := &binaryReader{data: data}
+	if magic := r.byte(); r.err == nil && magic != binaryMagic {
+		return fmt.Errorf("imacd: invalid binary magic byte 0x%02x", magic)
+	}
+	version := r.byte()
+	if r.err == nil && (version < 1 || version > binaryVersion) {
+		return fmt.Errorf("imacd: unsupported binary format version %d", version)
+	}
+
+	lengthMA := int(r.uint32())
+	lengthSignal := int(r.uint32())
+	count := int(r.uint64())
+	if r.err == nil && (lengthMA < 1 || lengthSignal < 1) {
+		return fmt.Errorf("imacd: invalid lengths (%d, %d)", lengthMA, lengthSignal)
+	}
+
+	bandLength := lengthMA
+	if im.bandLength > 0 {
+		bandLength = im.bandLength
+	}
+	high, low, mid := NewSMMA(bandLength), NewSMMA(bandLength), NewZLEMA(lengthMA)
+	high.value, high.isInit = r.float(), r.bool()
+	low.value, low.isInit = r.float(), r.bool()
+	mid.ema1.value, mid.ema1.isInit = r.float(), r.bool()
+	mid.ema2.value, mid.ema2.isInit = r.float(), r.bool()
+	mid.value = r.float()
+
+	sma := NewSMA(lengthSignal)
+	n := int(r.uint32())
+	if r.err == nil && n > lengthSignal {
+		return fmt.Errorf("imacd: signal window has %d values, want at most %d", n, lengthSignal)
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		sma.window.Push(r.float())
+	}
+	sma.sum = r.float()
+	sma.value = r.float()
+
+	nValues := r.uint64()
+	if r.err == nil && nValues > uint64(r.remaining()/25) {
+		return errors.New("imacd: binary data truncated")
+	}
+	values := make([]ImpulseValue, 0, nValues)
+	for i := uint64(0); i < nValues && r.err == nil; i++ {
+		v := ImpulseValue{MD: r.float(), SB: r.float(), SH: r.float()}
+		v.Valid = count-int(nValues)+int(i)+1 >= minBars(lengthMA, lengthSignal, bandLength)
+		code := r.byte()
+		if int(code) >= len(colorCodes) {
+			return fmt.Errorf("imacd: invalid color code %d", code)
+		}
+		v.Color = colorCodes[code]
+		var prevSH float64
+		if len(values) > 0 {
+			prevSH = values[len(values)-1].SH
+		}
+		v.HistColor = histColor(prevSH, v.SH)
+		values = append(values, v)
+	}
+
+	var minMax *minMaxScaler
+	if version >= 2 && r.bool() {
+		minMax = &minMaxScaler{window: int(r.uint32())}
+		minMax.min, minMax.max, minMax.seen = r.float(), r.float(), r.bool()
+		n := int(r.uint32())
+		if r.err == nil && (minMax.window == 0 && n != 0 || n > minMax.window) {
+			return fmt.Errorf("imacd: min-max window has %d values, want at most %d", n, minMax.window)
+		}
+		for i := 0; i < n && r.err == nil; i++ {
+			minMax.values = append(minMax.values, r.float())
+		}
+	}
+
+	var (
+		lastTime time.Time
+		sources  []float64
+		bands    []Bands
+		inputs   []barInput
+	)
+	if version >= 3 {
+		lastTime = r.time()
+		for i := range values {
+			values[i].Timestamp = r.time()
+		}
+		n := r.count(8)
+		for i := 0; i < n && r.err == nil; i++ {
+			sources = append(sources, r.float())
+		}
+		n = r.count(24)
+		for i := 0; i < n && r.err == nil; i++ {
+			bands = append(bands, Bands{r.float(), r.float(), r.float()})
+		}
+		n = r.count(33)
+		for i := 0; i < n && r.err == nil; i++ {
+			inputs = append(inputs, barInput{r.time(), r.float(), r.float(), r.float(), r.float()})
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	for _, s := range []struct {
+		name string
+		n    int
+	}{
+		{"sources", len(sources)},
+		{"bands", len(bands)},
+		{"inputs", len(inputs)},
+	} {
+		if s.n != 0 && s.n != len(values) {
+			return fmt.Errorf("imacd: %d retained %s for %d values", s.n, s.name, len(values))
+		}
+	}
+	if im.retainSources && sources == nil || im.retainBands && bands == nil ||
+		im.retainInputs && inputs == nil {
+		values = values[:0]
+	}
+	if r.remaining() != 0 {
+		return fmt.Errorf("imacd: %d trailing bytes after binary data", r.remaining())
+	}
+
+	im.lengthMA = lengthMA
+	im.lengthSignal = lengthSignal
+	im.maHigh, im.maLow, im.maMid, im.maSignal = high, low, mid, sma
+	setCounts(count, high, low, mid, sma)
+	im.applyTimeDecay()
+	im.values = values
+	im.count = count
+
+	// Drop everything left over from before the restore
+	if im.shSmoothing != nil {
+		im.shSmoothing.Reset()
+	}
+	if im.shStats != nil {
+		im.shStats.Reset()
+	}
+	if im.minMax != nil {
+		if minMax != nil && minMax.window == im.minMax.window {
+			im.minMax = minMax
+		} else {
+			im.minMax.reset()
+		}
+	}
+	im.sources, im.bands, im.inputs = nil, nil, nil
+	if im.retainSources {
+		im.sources = sources[:len(values):len(values)]
+	}
+	if im.retainBands {
+		im.bands = bands[:len(values):len(values)]
+	}
+	if im.retainInputs {
+		im.inputs = inputs[:len(values):len(values)]
+	}
+	im.lastTime = lastTime
+	im.forming, im.hasForming = ImpulseValue{}, false
+	im.crosses.reset()
+	im.checkpoints = nil
+	return nil
+}
+
+func colorCode(c Color) (byte, error) {
+	for i, known := range colorCodes {
+		if c == known {
+			return byte(i), nil
+		}
+	}
+	return 0, fmt.Errorf("imacd: unknown color %q", c)
+}
+
+func appendFloat(buf []byte, v float64) []byte {
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+}
+
+func appendTime(buf []byte, t time.Time) []byte {
+	buf = appendBool(buf, !t.IsZero())
+	if t.IsZero() {
+		return buf
+	}
+	return binary.LittleEndian.AppendUint64(buf, uint64(t.UnixNano()))
+}
+
+func appendBool(buf []byte, v bool) []byte {
+	if v {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+// binaryReader decodes little-endian fields, recording the first error so
+// callers can check once after a run of reads
+type binaryReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.off+n > len(r.data) {
+		r.err = errors.New("imacd: binary data truncated")
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *binaryReader) remaining() int {
+	return len(r.data) - r.off
+}
+
+func (r *binaryReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *binaryReader) bool() bool {
+	return r.byte() != 0
+}
+
+func (r *binaryReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) float() float64 {
+	return math.Float64frombits(r.uint64())
+}
+
+// time reads a timestamp written by appendTime, in UTC
+func (r *binaryReader) time() time.Time {
+	if !r.bool() {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(r.uint64())).UTC()
+}
+
+// count reads the length of a series of elements of at least size bytes,
+// failing when the remaining data cannot hold them
+func (r *binaryReader) count(size int) int {
+	n := int(r.uint32())
+	if r.err == nil && n > r.remaining()/size {
+		r.err = errors.New("imacd: binary data truncated")
+	}
+	return n
+}
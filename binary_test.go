@@ -0,0 +1,199 @@
+package imacd
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func sineBars(n int) []PriceBar {
+	bars := make([]PriceBar, n)
+	for i := range bars {
+		x := 100 + 10*math.Sin(float64(i)/6)
+		bars[i] = PriceBar{Open: x, High: x + 1.5, Low: x - 1.5, Close: x + 0.5}
+	}
+	return bars
+}
+
+// checkCoreValues compares the fields the binary format encodes per value,
+// and the Valid flag derived from the bar count
+func checkCoreValues(t *testing.T, want, got []ImpulseValue) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("restored %d values, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.MD != w.MD || g.SB != w.SB || g.SH != w.SH || g.Color != w.Color ||
+			g.HistColor != w.HistColor || g.Valid != w.Valid {
+			t.Fatalf("restored value %d is %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+// checkContinues feeds the same bars to both indicators and requires the same
+// core outputs from each
+func checkContinues(t *testing.T, a, b *ImpulseMACD, bars []PriceBar) {
+	t.Helper()
+	for i, bar := range bars {
+		va, vb := a.updateBar(bar), b.updateBar(bar)
+		if va.MD != vb.MD || va.SB != vb.SB || va.SH != vb.SH || va.Color != vb.Color || va.Valid != vb.Valid {
+			t.Fatalf("bar %d after restore: %+v, want %+v", i, vb, va)
+		}
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"default":  nil,
+		"min_max":  {WithPersistentMinMax(20)},
+		"retained": {WithRetainSources(), WithRetainBands(), WithRetainInputs()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			bars := sineBars(120)
+			im, _ := NewImpulseMACDWithOptions(10, 4, opts...)
+			im.BatchUpdate(bars[:80])
+
+			data, err := im.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			restored, _ := NewImpulseMACDWithOptions(10, 4, opts...)
+			if err := restored.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			checkCoreValues(t, im.GetValues(), restored.GetValues())
+			if !slices.Equal(im.sources, restored.sources) || len(im.bands) != len(restored.bands) ||
+				len(im.inputs) != len(restored.inputs) {
+				t.Fatal("retained series not restored")
+			}
+			checkContinues(t, im, restored, bars[80:])
+		})
+	}
+}
+
+// TestBinaryOlderVersions restores version 1 and 2 data, built by stripping
+// the fields later versions added from a version 3 encoding
+func TestBinaryOlderVersions(t *testing.T) {
+	bars := sineBars(100)
+	im, _ := NewImpulseMACDWithOptions(10, 4)
+	im.BatchUpdate(bars[:60])
+	data, err := im.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[1] != 3 {
+		t.Fatalf("version byte is %d, want 3", data[1])
+	}
+
+	// Version 3 appends the untimed latest time and value times, one byte
+	// each, and three empty series counts; version 2 appends the MD range flag
+	trailer := 1 + len(im.GetValues()) + 3*4
+	v2 := append([]byte(nil), data[:len(data)-trailer]...)
+	v2[1] = 2
+	v1 := append([]byte(nil), v2[:len(v2)-1]...)
+	v1[1] = 1
+
+	for version, old := range map[int][]byte{1: v1, 2: v2} {
+		restored, _ := NewImpulseMACDWithOptions(10, 4)
+		if err := restored.UnmarshalBinary(old); err != nil {
+			t.Fatalf("version %d: %v", version, err)
+		}
+		checkCoreValues(t, im.GetValues(), restored.GetValues())
+		next, _ := NewImpulseMACDWithOptions(10, 4)
+		next.UnmarshalBinary(data)
+		checkContinues(t, next, restored, bars[60:])
+	}
+}
+
+func TestBinaryRejects(t *testing.T) {
+	im, _ := NewImpulseMACDWithOptions(10, 4)
+	im.BatchUpdate(sineBars(30))
+	data, _ := im.MarshalBinary()
+
+	future := append([]byte(nil), data...)
+	future[1] = binaryVersion + 1
+	badMagic := append([]byte(nil), data...)
+	badMagic[0] = 'X'
+	for name, tc := range map[string]struct {
+		data []byte
+		want string
+	}{
+		"future version": {future, "unsupported binary format version 4"},
+		"bad magic":      {badMagic, "invalid binary magic byte"},
+		"truncated":      {data[:len(data)-5], "truncated"},
+		"trailing":       {append(append([]byte(nil), data...), 0), "trailing bytes"},
+		"empty":          {nil, "truncated"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			restored, _ := NewImpulseMACDWithOptions(10, 4)
+			err := restored.UnmarshalBinary(tc.data)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error %v, want one containing %q", err, tc.want)
+			}
+			if restored.count != 0 {
+				t.Fatal("a rejected restore changed the indicator")
+			}
+		})
+	}
+}
+
+func TestBinaryKeepsCustomMovingAverages(t *testing.T) {
+	im, _ := NewImpulseMACDWithOptions(10, 4)
+	im.BatchUpdate(sineBars(30))
+	data, _ := im.MarshalBinary()
+
+	for name, opts := range map[string][]Option{
+		"tv_rma":    {WithTradingViewRMA()},
+		"multi_ema": {WithMidDepth(3)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			restored, _ := NewImpulseMACDWithOptions(10, 4, opts...)
+			cfg := restored.Config()
+			if err := restored.UnmarshalBinary(data); !errors.Is(err, ErrCustomMovingAverage) {
+				t.Fatalf("error %v, want ErrCustomMovingAverage", err)
+			}
+			if restored.Config() != cfg {
+				t.Fatalf("config changed to %+v", restored.Config())
+			}
+		})
+	}
+}
+
+// TestBinaryRestoresValid checks that Valid, which the format does not
+// store, is re-derived for every restored value, including when the history
+// limit dropped the first bars or a band length raises the warmup
+func TestBinaryRestoresValid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		bars int
+	}{
+		{"cold", nil, 3},
+		{"warming", nil, 14},
+		{"trimmed", []Option{WithMaxHistory(6)}, 14},
+		{"band_length", []Option{WithBandLength(13)}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := newIndicator(t, tt.opts...)
+			im.BatchUpdate(sineBars(tt.bars))
+			data, err := im.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			restored := newIndicator(t, tt.opts...)
+			if err := restored.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range restored.GetValues() {
+				bar := im.count - len(im.GetValues()) + i + 1
+				if want := bar >= im.MinBars(); v.Valid != want || v.Valid != im.GetValues()[i].Valid {
+					t.Fatalf("value %d (bar %d): Valid %t, want %t", i, bar, v.Valid, want)
+				}
+			}
+		})
+	}
+}
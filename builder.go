@@ -0,0 +1,104 @@
+package imacd
+
+import "fmt"
+
+// PriceSource is a common source price for Builder.PriceSource
+type PriceSource int
+
+// Price sources
+const (
+	PriceHLC3  PriceSource = iota // (high + low + close) / 3, the default
+	PriceHL2                      // (high + low) / 2
+	PriceOHLC4                    // (open + high + low + close) / 4
+	PriceClose                    // The close alone
+)
+
+// Builder configures an indicator with chained calls as an alternative to
+// functional options:
+//
+//	im, err := imacd.NewBuilder().MALength(34).SignalLength(9).MaxHistory(5000).Build()
+//
+// The calls only record the settings; Build validates them all at once, with
+// the same checks as NewFromConfig and NewImpulseMACDWithOptions.
+type Builder struct {
+	cfg    Config
+	source PriceSource
+	opts   []Option
+}
+
+// NewBuilder creates a builder starting from the default lengths (34, 9)
+func NewBuilder() *Builder {
+	return &Builder{cfg: Config{LengthMA: DefaultLengthMA, LengthSignal: DefaultLengthSignal}}
+}
+
+// MALength sets the length of the bands and the mid line
+func (b *Builder) MALength(n int) *Builder {
+	b.cfg.LengthMA = n
+	return b
+}
+
+// SignalLength sets the length of the signal line
+func (b *Builder) SignalLength(n int) *Builder {
+	b.cfg.LengthSignal = n
+	return b
+}
+
+// BandLength sets a band length different from the MA length, like
+// WithBandLength
+func (b *Builder) BandLength(n int) *Builder {
+	b.cfg.BandLength = n
+	return b
+}
+
+// MidMA sets the mid line moving average: MAZlema or, with MidDepth,
+// MAMultiEMA
+func (b *Builder) MidMA(ma MAType) *Builder {
+	b.cfg.MidMA = ma
+	return b
+}
+
+// MidDepth sets the depth of an MAMultiEMA mid line, like WithMidDepth
+func (b *Builder) MidDepth(depth int) *Builder {
+	b.cfg.MidMA, b.cfg.MidDepth = MAMultiEMA, depth
+	return b
+}
+
+// PriceSource sets the source price feeding the mid line
+func (b *Builder) PriceSource(src PriceSource) *Builder {
+	b.source = src
+	switch src {
+	case PriceHLC3:
+		b.cfg.PriceWeights = nil
+	case PriceHL2:
+		b.cfg.PriceWeights = &PriceWeights{High: 1, Low: 1}
+	case PriceOHLC4:
+		b.cfg.PriceWeights = &PriceWeights{Open: 1, High: 1, Low: 1, Close: 1}
+	case PriceClose:
+		b.cfg.PriceWeights = &PriceWeights{Close: 1}
+	}
+	return b
+}
+
+// MaxHistory limits the number of values retained, like WithMaxHistory
+func (b *Builder) MaxHistory(n int) *Builder {
+	b.cfg.MaxHistory = n
+	return b
+}
+
+// Options adds functional options, applied after the builder's settings
+func (b *Builder) Options(opts ...Option) *Builder {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Build validates the settings and creates the indicator
+func (b *Builder) Build() (*ImpulseMACD, error) {
+	if b.source < PriceHLC3 || b.source > PriceClose {
+		return nil, fmt.Errorf("imacd: unknown price source %d", b.source)
+	}
+	opts, err := b.cfg.options()
+	if err != nil {
+		return nil, err
+	}
+	return NewImpulseMACDWithOptions(b.cfg.LengthMA, b.cfg.LengthSignal, append(opts, b.opts...)...)
+}
@@ -0,0 +1,97 @@
+package imacd
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// CachedComputer memoizes batch computations keyed by the lengths and a hash
+// of the bars, so repeated queries over the same range with the same settings
+// return immediately. It is safe for concurrent use.
+type CachedComputer struct {
+	mu           sync.Mutex
+	lengthMA     int
+	lengthSignal int
+	opts         []Option
+	cache        map[cacheKey][]ImpulseValue
+}
+
+type cacheKey struct {
+	lengthMA     int
+	lengthSignal int
+	bars         int
+	hash         uint64
+}
+
+// NewCachedComputer creates a cache computing with the given lengths; the
+// options are applied to every indicator it creates
+func NewCachedComputer(lengthMA, lengthSignal int, opts ...Option) *CachedComputer {
+	return &CachedComputer{
+		lengthMA:     lengthMA,
+		lengthSignal: lengthSignal,
+		opts:         opts,
+		cache:        make(map[cacheKey][]ImpulseValue),
+	}
+}
+
+// SetParams changes the lengths, invalidating the cache when they differ
+func (c *CachedComputer) SetParams(lengthMA, lengthSignal int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lengthMA == c.lengthMA && lengthSignal == c.lengthSignal {
+		return
+	}
+	c.lengthMA, c.lengthSignal = lengthMA, lengthSignal
+	c.cache = make(map[cacheKey][]ImpulseValue)
+}
+
+// Compute returns the values of a fresh indicator over bars, from the cache
+// when the same bars were computed with the current lengths. The result is a
+// copy the caller may modify.
+func (c *CachedComputer) Compute(bars []PriceBar) ([]ImpulseValue, error) {
+	c.mu.Lock()
+	key := cacheKey{c.lengthMA, c.lengthSignal, len(bars), hashBars(bars)}
+	values, ok := c.cache[key]
+	opts := c.opts
+	c.mu.Unlock()
+
+	if !ok {
+		im, err := NewImpulseMACDWithOptions(key.lengthMA, key.lengthSignal, opts...)
+		if err != nil {
+			return nil, err
+		}
+		values = im.BatchUpdate(bars)
+
+		c.mu.Lock()
+		if key.lengthMA == c.lengthMA && key.lengthSignal == c.lengthSignal {
+			c.cache[key] = values
+		}
+		c.mu.Unlock()
+	}
+
+	result := make([]ImpulseValue, len(values))
+	copy(result, values)
+	return result, nil
+}
+
+// hashBars computes an FNV-1a hash over the prices, quotes, sources and
+// times of bars
+func hashBars(bars []PriceBar) uint64 {
+	h := fnv.New64a()
+	var buf [72]byte
+	for _, bar := range bars {
+		binary.LittleEndian.PutUint64(buf[0:], math.Float64bits(bar.Open))
+		binary.LittleEndian.PutUint64(buf[8:], math.Float64bits(bar.High))
+		binary.LittleEndian.PutUint64(buf[16:], math.Float64bits(bar.Low))
+		binary.LittleEndian.PutUint64(buf[24:], math.Float64bits(bar.Close))
+		binary.LittleEndian.PutUint64(buf[32:], math.Float64bits(bar.Bid))
+		binary.LittleEndian.PutUint64(buf[40:], math.Float64bits(bar.Ask))
+		binary.LittleEndian.PutUint64(buf[48:], math.Float64bits(bar.Source))
+		binary.LittleEndian.PutUint64(buf[56:], uint64(bar.Time.Unix()))
+		binary.LittleEndian.PutUint64(buf[64:], uint64(bar.Time.Nanosecond()))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
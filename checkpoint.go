@@ -0,0 +1,280 @@
+package imacd
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoCheckpoint is returned by PopCheckpoint when no checkpoint is active
+var ErrNoCheckpoint = errors.New("imacd: no active checkpoint")
+
+// checkpointer is implemented by moving averages that can save their state on
+// a stack and restore it later, or drop the saved state without restoring it;
+// all of the built-in averages implement it
+type checkpointer interface {
+	push()
+	pop()
+	drop()
+}
+
+// checkpoint is the indicator level state saved by PushCheckpoint
+type checkpoint struct {
+	count        int
+	valuesLen    int
+	crossLast    CrossType
+	crossAt      [3]int
+	plateauStart ImpulseValue
+	inPlateau    bool
+	lastTime     time.Time
+	minMax       *minMaxScaler
+	// The latest value and its retained series entries, put back when a
+	// history limit trimmed every value kept at the checkpoint
+	last      ImpulseValue
+	lastSrc   float64
+	lastBand  Bands
+	lastInput barInput
+	// Saved by TimestampMerge before the latest timed bar, not by the caller
+	merge bool
+}
+
+// PushCheckpoint saves the current state so a later PopCheckpoint can undo
+// all updates made since. Checkpoints nest, and each one holds a copy of the
+// sub-indicator state, including the signal window. Reset discards them.
+func (im *ImpulseMACD) PushCheckpoint() error {
+	im.checkReentry()
+	im.dropMergePoint()
+	return im.pushCheckpoint(false)
+}
+
+func (im *ImpulseMACD) pushCheckpoint(merge bool) error {
+	im.checkReentry()
+	mas := im.checkpointers()
+	if mas == nil {
+		return ErrCustomMovingAverage
+	}
+
+	for _, ma := range mas {
+		ma.push()
+	}
+	cp := checkpoint{
+		count:        im.count,
+		valuesLen:    len(im.values),
+		crossLast:    im.crosses.last,
+		crossAt:      im.crosses.lastAt,
+		plateauStart: im.crosses.plateauStart,
+		inPlateau:    im.crosses.inPlateau,
+		lastTime:     im.lastTime,
+		merge:        merge,
+	}
+	if im.minMax != nil {
+		cp.minMax = im.minMax.clone()
+	}
+	if n := len(im.values); n > 0 {
+		cp.last = im.values[n-1]
+		if im.retainSources {
+			cp.lastSrc = im.sources[n-1]
+		}
+		if im.retainBands {
+			cp.lastBand = im.bands[n-1]
+		}
+		if im.retainInputs {
+			cp.lastInput = im.inputs[n-1]
+		}
+	}
+	im.checkpoints = append(im.checkpoints, cp)
+	return nil
+}
+
+// PopCheckpoint restores the state saved by the most recent PushCheckpoint,
+// dropping the values calculated since. Values trimmed by a history limit
+// after the checkpoint was pushed cannot be brought back, except the latest
+// one, which the histogram color and cross tracking of the next bar need.
+func (im *ImpulseMACD) PopCheckpoint() error {
+	im.checkReentry()
+	im.dropMergePoint()
+	return im.popCheckpoint()
+}
+
+func (im *ImpulseMACD) popCheckpoint() error {
+	im.checkReentry()
+	if len(im.checkpoints) == 0 {
+		return ErrNoCheckpoint
+	}
+
+	cp := im.checkpoints[len(im.checkpoints)-1]
+	im.checkpoints = im.checkpoints[:len(im.checkpoints)-1]
+	for _, ma := range im.checkpointers() {
+		ma.pop()
+	}
+
+	keep := max(0, min(len(im.values)-(im.count-cp.count), cp.valuesLen))
+	if keep == 0 && cp.valuesLen > 0 {
+		im.values = append(im.values[:0], cp.last)
+		if im.retainSources {
+			im.sources = append(im.sources[:0], cp.lastSrc)
+		}
+		if im.retainBands {
+			im.bands = append(im.bands[:0], cp.lastBand)
+		}
+		if im.retainInputs {
+			im.inputs = append(im.inputs[:0], cp.lastInput)
+		}
+	} else {
+		im.values = im.values[:keep]
+		if im.retainSources {
+			im.sources = im.sources[:keep]
+		}
+		if im.retainBands {
+			im.bands = im.bands[:keep]
+		}
+		if im.retainInputs {
+			im.inputs = im.inputs[:keep]
+		}
+	}
+	im.count = cp.count
+	im.crosses.last = cp.crossLast
+	im.crosses.lastAt = cp.crossAt
+	im.crosses.plateauStart, im.crosses.inPlateau = cp.plateauStart, cp.inPlateau
+	im.lastTime = cp.lastTime
+	if cp.minMax != nil {
+		im.minMax = cp.minMax
+	}
+	return nil
+}
+
+// dropMergePoint discards the checkpoint kept by TimestampMerge, if it is the
+// innermost one, so the latest timed bar can no longer be merged into
+func (im *ImpulseMACD) dropMergePoint() {
+	n := len(im.checkpoints)
+	if n == 0 || !im.checkpoints[n-1].merge {
+		return
+	}
+	for _, ma := range im.checkpointers() {
+		ma.drop()
+	}
+	im.checkpoints = im.checkpoints[:n-1]
+}
+
+// checkpointers returns every stateful sub-indicator, or nil when any of them
+// does not support checkpoints
+func (im *ImpulseMACD) checkpointers() []checkpointer {
+	mas := []MovingAverage{im.maHigh, im.maLow, im.maMid, im.maSignal}
+	if im.shSmoothing != nil {
+		mas = append(mas, im.shSmoothing)
+	}
+
+	result := make([]checkpointer, 0, len(mas))
+	for _, ma := range mas {
+		cp, ok := ma.(checkpointer)
+		if !ok {
+			return nil
+		}
+		result = append(result, cp)
+	}
+	if im.shStats != nil {
+		result = append(result, im.shStats)
+	}
+	return result
+}
+
+type averageState struct {
+	value  float64
+	isInit bool
+	count  int
+}
+
+func (s *SMMA) push() {
+	s.saved = append(s.saved, averageState{s.value, s.isInit, s.count})
+}
+
+func (s *SMMA) pop() {
+	st := s.saved[len(s.saved)-1]
+	s.saved = s.saved[:len(s.saved)-1]
+	s.value, s.isInit, s.count = st.value, st.isInit, st.count
+}
+
+func (s *SMMA) drop() {
+	s.saved = s.saved[:len(s.saved)-1]
+}
+
+func (e *EMA) push() {
+	e.saved = append(e.saved, averageState{e.value, e.isInit, e.count})
+}
+
+func (e *EMA) pop() {
+	st := e.saved[len(e.saved)-1]
+	e.saved = e.saved[:len(e.saved)-1]
+	e.value, e.isInit, e.count = st.value, st.isInit, st.count
+}
+
+func (e *EMA) drop() {
+	e.saved = e.saved[:len(e.saved)-1]
+}
+
+func (z *ZLEMA) push() {
+	z.ema1.push()
+	z.ema2.push()
+	z.saved = append(z.saved, z.value)
+}
+
+func (z *ZLEMA) pop() {
+	z.ema1.pop()
+	z.ema2.pop()
+	z.value = z.saved[len(z.saved)-1]
+	z.saved = z.saved[:len(z.saved)-1]
+}
+
+func (z *ZLEMA) drop() {
+	z.ema1.drop()
+	z.ema2.drop()
+	z.saved = z.saved[:len(z.saved)-1]
+}
+
+type rollingState struct {
+	values   []float64
+	next     int
+	mean, m2 float64
+}
+
+func (r *RollingStats) push() {
+	values := make([]float64, len(r.values))
+	copy(values, r.values)
+	r.saved = append(r.saved, rollingState{values, r.next, r.mean, r.m2})
+}
+
+func (r *RollingStats) pop() {
+	st := r.saved[len(r.saved)-1]
+	r.saved = r.saved[:len(r.saved)-1]
+	r.values = append(r.values[:0], st.values...)
+	r.next, r.mean, r.m2 = st.next, st.mean, st.m2
+}
+
+func (r *RollingStats) drop() {
+	r.saved = r.saved[:len(r.saved)-1]
+}
+
+type smaState struct {
+	values []float64
+	sum    float64
+	value  float64
+	count  int
+}
+
+func (s *SMA) push() {
+	values := s.window.Values()
+	s.saved = append(s.saved, smaState{values, s.sum, s.value, s.count})
+}
+
+func (s *SMA) pop() {
+	st := s.saved[len(s.saved)-1]
+	s.saved = s.saved[:len(s.saved)-1]
+	s.window.Reset()
+	for _, v := range st.values {
+		s.window.Push(v)
+	}
+	s.sum, s.value, s.count = st.sum, st.value, st.count
+}
+
+func (s *SMA) drop() {
+	s.saved = s.saved[:len(s.saved)-1]
+}
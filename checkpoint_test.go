@@ -0,0 +1,124 @@
+package imacd
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// replay returns a fresh indicator fed the bars, the state a checkpoint
+// taken after them must restore
+func replay(t *testing.T, bars []PriceBar, opts ...Option) *ImpulseMACD {
+	t.Helper()
+	im, err := NewImpulseMACDWithOptions(8, 5, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bar := range bars {
+		im.Update(bar.High, bar.Low, bar.Close)
+	}
+	return im
+}
+
+func TestCheckpointNestedUndo(t *testing.T) {
+	opts := []Option{
+		WithHistogramSmoothing(3),
+		WithSHZScore(4),
+		WithPersistentMinMax(6),
+		WithRetainSources(),
+		WithRetainBands(),
+	}
+	bars := randomBars(rand.New(rand.NewPCG(4, 123)), 40)
+	im := replay(t, bars[:10], opts...)
+
+	// Push a checkpoint after 10, 20 and 30 bars
+	var marks []int
+	for n := 10; n < 40; n += 10 {
+		if err := im.PushCheckpoint(); err != nil {
+			t.Fatal(err)
+		}
+		marks = append(marks, n)
+		for _, bar := range bars[n : n+10] {
+			im.Update(bar.High, bar.Low, bar.Close)
+		}
+	}
+
+	for len(marks) > 0 {
+		n := marks[len(marks)-1]
+		marks = marks[:len(marks)-1]
+		if err := im.PopCheckpoint(); err != nil {
+			t.Fatal(err)
+		}
+		if !im.Equal(replay(t, bars[:n], opts...)) {
+			t.Fatalf("state after popping back to %d bars differs from a replay", n)
+		}
+	}
+	if err := im.PopCheckpoint(); !errors.Is(err, ErrNoCheckpoint) {
+		t.Fatalf("PopCheckpoint without a checkpoint = %v, want ErrNoCheckpoint", err)
+	}
+
+	// The restored indicator carries on as if the undone bars never came
+	want := replay(t, bars[:10], opts...)
+	for _, bar := range bars[30:] {
+		if got, exp := im.Update(bar.High, bar.Low, bar.Close), want.Update(bar.High, bar.Low, bar.Close); got != exp {
+			t.Fatalf("after undo: %+v, want %+v", got, exp)
+		}
+	}
+}
+
+// TestCheckpointHistoryLimit checks that popping after the history limit or
+// TrimHistory dropped values keeps only the values both sides still hold, or
+// the latest one at the checkpoint, while the state is fully restored
+func TestCheckpointHistoryLimit(t *testing.T) {
+	bars := randomBars(rand.New(rand.NewPCG(5, 123)), 60)
+	tests := []struct {
+		name  string
+		opts  []Option
+		after int // Bars updated after the checkpoint
+		trim  int // TrimHistory after them, 0 for none
+		keep  int // Values left after the pop
+	}{
+		{"max_history_partial", []Option{WithMaxHistory(10)}, 3, 0, 7},
+		{"max_history_all", []Option{WithMaxHistory(10), WithRetainSources(), WithRetainInputs()}, 20, 0, 1},
+		{"trim", nil, 5, 8, 3},
+		{"trim_all", []Option{WithRetainBands()}, 5, 4, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := replay(t, bars[:15], tt.opts...)
+			if err := im.PushCheckpoint(); err != nil {
+				t.Fatal(err)
+			}
+			for _, bar := range bars[15 : 15+tt.after] {
+				im.Update(bar.High, bar.Low, bar.Close)
+			}
+			if tt.trim > 0 {
+				im.TrimHistory(tt.trim)
+			}
+			if err := im.PopCheckpoint(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := replay(t, bars[:15], tt.opts...)
+			got, all := im.GetValues(), want.GetValues()
+			if len(got) != tt.keep || !slices.Equal(got, all[len(all)-tt.keep:]) {
+				t.Fatalf("kept %d values, want the last %d of the replay", len(got), tt.keep)
+			}
+			if !slices.Equal(im.sources, want.sources[len(want.sources)-len(im.sources):]) ||
+				im.retainSources && len(im.sources) != tt.keep ||
+				im.retainBands && len(im.bands) != tt.keep ||
+				im.retainInputs && len(im.inputs) != tt.keep {
+				t.Fatal("retained series out of step with the values")
+			}
+			if im.count != want.count {
+				t.Fatalf("count %d, want %d", im.count, want.count)
+			}
+			for _, bar := range bars[15+tt.after:] {
+				if got, exp := im.Update(bar.High, bar.Low, bar.Close), want.Update(bar.High, bar.Low, bar.Close); got != exp {
+					t.Fatalf("after pop: %+v, want %+v", got, exp)
+				}
+			}
+		})
+	}
+}
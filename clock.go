@@ -0,0 +1,37 @@
+package imacd
+
+import (
+	"errors"
+	"time"
+)
+
+// Clock tells the time for updates made without a timestamp, see WithClock
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock reading the wall clock
+type SystemClock struct{}
+
+// Now returns the current local time
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// WithClock stamps updates made without a timestamp, such as Update,
+// UpdateOHLC, UpdateTypical, UpdateForming and untimed batch bars, with
+// clock.Now(), so time decay and GetValuesSince work for live feeds whose
+// bars carry no times. Pass SystemClock{} for the wall clock, or a fake clock
+// to drive the time-based features deterministically in tests. Without it
+// untimed updates keep the zero time, as a wall-clock default would make
+// every untimed result depend on when it was computed. The timestamp policy
+// does not apply to clock stamps, and a clock going back counts as no elapsed
+// time. Like the MD transform, the clock is not part of the Config, not
+// compared by Equal and not serialized.
+func WithClock(clock Clock) Option {
+	return func(im *ImpulseMACD) error {
+		if clock == nil {
+			return errors.New("imacd: clock must not be nil")
+		}
+		im.clock = clock
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package imacd
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when told to
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// TestClockStampsUntimedUpdates drives time decay through untimed updates and
+// a fake clock, and checks them against UpdateAt with the same times
+func TestClockStampsUntimedUpdates(t *testing.T) {
+	clock := &fakeClock{now: tsStart}
+	stamped := newIndicator(t, WithClock(clock), WithTimeDecay(2*time.Minute))
+	timed := newIndicator(t, WithTimeDecay(2*time.Minute))
+
+	rng := rand.New(rand.NewPCG(14, 176))
+	for i, bar := range randomBars(rng, 100) {
+		clock.advance(time.Duration(1+rng.IntN(300)) * time.Second)
+		got := stamped.Update(bar.High, bar.Low, bar.Close)
+		want, err := timed.UpdateAt(clock.now, bar.High, bar.Low, bar.Close)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want || !got.Timestamp.Equal(clock.now) {
+			t.Fatalf("bar %d: %+v, want %+v stamped %v", i, got, want, clock.now)
+		}
+	}
+	if since := stamped.GetValuesSince(clock.now); len(since) != 1 {
+		t.Fatalf("GetValuesSince the latest stamp returned %d values, want 1", len(since))
+	}
+
+	// A clock going back counts as no elapsed time instead of failing
+	clock.advance(-time.Hour)
+	if v := stamped.Update(10, 9, 9.5); !v.Timestamp.Equal(clock.now) {
+		t.Fatalf("stamp after the clock went back = %v, want %v", v.Timestamp, clock.now)
+	}
+}
+
+func TestClockSurvivesRestore(t *testing.T) {
+	clock := &fakeClock{now: tsStart}
+	im := newIndicator(t, WithClock(clock))
+	im.Update(10, 9, 9.5)
+	data, err := im.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := im.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(time.Minute)
+	if v := im.Update(11, 10, 10.5); !v.Timestamp.Equal(clock.now) {
+		t.Fatalf("stamp after a restore = %v, want %v", v.Timestamp, clock.now)
+	}
+}
+
+func TestNoClockKeepsZeroTime(t *testing.T) {
+	if _, err := NewImpulseMACDWithOptions(8, 5, WithClock(nil)); err == nil {
+		t.Fatal("WithClock(nil) accepted")
+	}
+	im := newIndicator(t)
+	if v := im.Update(10, 9, 9.5); !v.Timestamp.IsZero() {
+		t.Fatalf("untimed stamp without a clock = %v, want the zero time", v.Timestamp)
+	}
+	if now := (SystemClock{}).Now(); now.IsZero() {
+		t.Fatal("SystemClock returned the zero time")
+	}
+}
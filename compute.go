@@ -0,0 +1,45 @@
+package imacd
+
+import "fmt"
+
+// ComputeAt returns the value of bars[i] given only bars[:i+1], by running a
+// fresh indicator with the same configuration up to i. The receiver is not
+// updated, and custom moving averages fall back to the default ones while
+// the other options still apply. It is O(i), meant for inspection rather
+// than streaming, and returns an error when i is out of range.
+func (im *ImpulseMACD) ComputeAt(bars []PriceBar, i int) (ImpulseValue, error) {
+	if i < 0 || i >= len(bars) {
+		return ImpulseValue{}, fmt.Errorf("imacd: bar index %d out of range [0, %d)", i, len(bars))
+	}
+
+	calc := newFromConfigOrDefault(im.Config(), im)
+	calc.maxHistory = 1
+	var value ImpulseValue
+	for _, bar := range bars[:i+1] {
+		value = calc.updateBar(bar)
+	}
+	return value, nil
+}
+
+// Compute runs the bars through a fresh indicator created from cfg and
+// returns the value of every bar
+func Compute(bars []PriceBar, cfg Config) ([]ImpulseValue, error) {
+	im, err := NewFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return im.BatchUpdate(bars), nil
+}
+
+// CompareConfigs computes the same bars with two configurations for A/B
+// evaluation. The results are aligned: index i of both holds the value of
+// bars[i]. An invalid configuration returns an error naming it.
+func CompareConfigs(bars []PriceBar, a, b Config) (resultA, resultB []ImpulseValue, err error) {
+	if resultA, err = Compute(bars, a); err != nil {
+		return nil, nil, fmt.Errorf("config a: %w", err)
+	}
+	if resultB, err = Compute(bars, b); err != nil {
+		return nil, nil, fmt.Errorf("config b: %w", err)
+	}
+	return resultA, resultB, nil
+}
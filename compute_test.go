@@ -0,0 +1,42 @@
+package imacd
+
+import "testing"
+
+// TestComputeAtCustomKeepsOptions checks that ComputeAt on an indicator with
+// custom moving averages falls back to the default averages and lengths but
+// keeps the other options
+func TestComputeAtCustomKeepsOptions(t *testing.T) {
+	opts := []Option{WithOutputPrecision(2), WithNeutralBand(0.1), WithColorSource(ColorFromClose), WithLogPrice()}
+	im := NewImpulseMACDWithMAs(NewEMA(10), NewEMA(10), NewEMA(10), NewEMA(4))
+	for _, opt := range opts {
+		if err := opt(im); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ref, err := NewImpulseMACDWithOptions(DefaultLengthMA, DefaultLengthSignal, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bars := sineBars(80)
+	want := ref.BatchUpdate(bars)
+	for _, i := range []int{0, 40, 79} {
+		got, err := im.ComputeAt(bars, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want[i] {
+			t.Fatalf("ComputeAt(%d) = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestComputeAtBounds(t *testing.T) {
+	im := NewDefaultImpulseMACD()
+	bars := sineBars(5)
+	for _, i := range []int{-1, 5} {
+		if _, err := im.ComputeAt(bars, i); err == nil {
+			t.Fatalf("ComputeAt(%d) on %d bars returned no error", i, len(bars))
+		}
+	}
+}
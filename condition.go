@@ -0,0 +1,90 @@
+package imacd
+
+// Condition is a predicate on a bar, given as the values up to and including
+// that bar, oldest first. Most conditions only look at the last value;
+// conditions comparing with earlier bars, like HistogramRising, are false
+// when there are not enough of them. Combine conditions with And, Or and
+// Not, and apply them with Evaluate, EvaluateAt or Holds.
+type Condition func(values []ImpulseValue) bool
+
+// Evaluate applies the condition to a single value with no history before
+// it, so conditions needing earlier bars are false
+func (c Condition) Evaluate(v ImpulseValue) bool {
+	return c([]ImpulseValue{v})
+}
+
+// EvaluateAt applies the condition to values[i], with the values before it as
+// history; it is false when i is out of range
+func (c Condition) EvaluateAt(values []ImpulseValue, i int) bool {
+	if i < 0 || i >= len(values) {
+		return false
+	}
+	return c(values[:i+1])
+}
+
+// Holds applies the condition to the latest stored value, false when there
+// is none
+func (im *ImpulseMACD) Holds(c Condition) bool {
+	return c.EvaluateAt(im.values, len(im.values)-1)
+}
+
+// And holds when every condition holds, and for no conditions
+func And(conds ...Condition) Condition {
+	return func(values []ImpulseValue) bool {
+		for _, c := range conds {
+			if !c(values) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or holds when any condition holds, and not for no conditions
+func Or(conds ...Condition) Condition {
+	return func(values []ImpulseValue) bool {
+		for _, c := range conds {
+			if c(values) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not holds when c does not
+func Not(c Condition) Condition {
+	return func(values []ImpulseValue) bool {
+		return !c(values)
+	}
+}
+
+// MDAboveSignal holds when MD is above SB
+func MDAboveSignal() Condition {
+	return func(values []ImpulseValue) bool {
+		v := values[len(values)-1]
+		return v.MD > v.SB
+	}
+}
+
+// ColorIs holds when the bar has the given color
+func ColorIs(color Color) Condition {
+	return func(values []ImpulseValue) bool {
+		return values[len(values)-1].Color == color
+	}
+}
+
+// HistogramRising holds when SH is above that of the previous bar
+func HistogramRising() Condition {
+	return func(values []ImpulseValue) bool {
+		n := len(values)
+		return n >= 2 && values[n-1].SH > values[n-2].SH
+	}
+}
+
+// IsValid holds once the indicator was warmed up at the bar
+func IsValid() Condition {
+	return func(values []ImpulseValue) bool {
+		return values[len(values)-1].Valid
+	}
+}
@@ -0,0 +1,275 @@
+package imacd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MAType names the moving average used for a line of the indicator
+type MAType string
+
+// Moving average types
+const (
+	MASmma     MAType = "smma"      // Smoothed moving average, the default for the bands
+	MAZlema    MAType = "zlema"     // Zero lag EMA, the default for the mid line
+	MASma      MAType = "sma"       // Simple moving average, the default for the signal
+	MARma      MAType = "rma"       // TradingView's ta.rma for the bands, see WithTradingViewRMA
+	MAMultiEMA MAType = "multi_ema" // A MultiEMA for the mid line, see WithMidDepth
+	MACustom   MAType = "custom"    // A custom MovingAverage, which cannot be configured
+)
+
+// PriceWeights are the weights of the source price set with WithPriceWeights
+type PriceWeights struct {
+	Open  float64 `json:"open"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Close float64 `json:"close"`
+}
+
+// ExtremeBoost is the setting of WithExtremeBoost
+type ExtremeBoost struct {
+	Factor   float64 `json:"factor"`
+	Multiple float64 `json:"multiple"`
+}
+
+// Config is the serializable configuration of an indicator. Zero values mean
+// the defaults, and MA types left empty mean the default type for the line.
+// Handlers registered with OnCross or OnValue and the MD transform are not
+// part of the configuration.
+type Config struct {
+	LengthMA     int `json:"length_ma"`
+	LengthSignal int `json:"length_signal"`
+	BandLength   int `json:"band_length,omitempty"`
+
+	BandMA   MAType `json:"band_ma,omitempty"`
+	MidMA    MAType `json:"mid_ma,omitempty"`
+	MidDepth int    `json:"mid_depth,omitempty"` // With MidMA set to MAMultiEMA
+	SignalMA MAType `json:"signal_ma,omitempty"`
+
+	// Source price weights, nil for HLC3
+	PriceWeights *PriceWeights `json:"price_weights,omitempty"`
+	LogPrice     bool          `json:"log_price,omitempty"`
+
+	MaxHistory         int              `json:"max_history,omitempty"`
+	HistogramSmoothing int              `json:"histogram_smoothing,omitempty"`
+	SHZScoreWindow     int              `json:"sh_zscore_window,omitempty"`
+	OutputPrecision    int              `json:"output_precision,omitempty"`
+	CrossDebounce      int              `json:"cross_debounce,omitempty"`
+	RetainSources      bool             `json:"retain_sources,omitempty"`
+	RetainBands        bool             `json:"retain_bands,omitempty"`
+	RetainInputs       bool             `json:"retain_inputs,omitempty"`
+	NeutralBand        float64          `json:"neutral_band,omitempty"`
+	ColorEpsilon       float64          `json:"color_epsilon,omitempty"`
+	ColorSource        ColorSource      `json:"color_source,omitempty"`
+	Explain            bool             `json:"explain,omitempty"`
+	TimeDecay          time.Duration    `json:"time_decay,omitempty"`
+	TimestampPolicy    TimestampPolicy  `json:"timestamp_policy,omitempty"`
+	GradedColor        bool             `json:"graded_color,omitempty"`
+	PersistentMinMax   bool             `json:"persistent_min_max,omitempty"`
+	MinMaxWindow       int              `json:"min_max_window,omitempty"`
+	SampleStatistics   bool             `json:"sample_statistics,omitempty"`
+	LatestForming      bool             `json:"latest_includes_forming,omitempty"`
+	ExtremeBoost       *ExtremeBoost    `json:"extreme_boost,omitempty"`
+	WithoutHistogram   bool             `json:"without_histogram,omitempty"`
+	PercentMD          bool             `json:"percent_md,omitempty"`
+	SubscriberPolicy   SubscriberPolicy `json:"subscriber_policy,omitempty"`
+	SubscriberBuffer   int              `json:"subscriber_buffer,omitempty"`
+	InputSelector      InputSelector    `json:"input_selector,omitempty"`
+}
+
+// Config returns the configuration of the indicator. Indicators built from
+// custom moving averages report MACustom for those lines.
+func (im *ImpulseMACD) Config() Config {
+	cfg := Config{
+		LengthMA:         im.lengthMA,
+		LengthSignal:     im.lengthSignal,
+		BandLength:       im.bandLength,
+		BandMA:           MACustom,
+		MidMA:            MACustom,
+		SignalMA:         MACustom,
+		LogPrice:         im.logPrice,
+		MaxHistory:       im.maxHistory,
+		CrossDebounce:    im.crosses.debounce,
+		RetainSources:    im.retainSources,
+		RetainBands:      im.retainBands,
+		RetainInputs:     im.retainInputs,
+		NeutralBand:      im.neutralBand,
+		ColorEpsilon:     im.colorEps,
+		ColorSource:      im.colorSource,
+		Explain:          im.explain,
+		TimeDecay:        im.timeDecay,
+		TimestampPolicy:  im.timestamps,
+		GradedColor:      im.gradedColor,
+		SampleStatistics: im.sampleStats,
+		LatestForming:    im.latestForming,
+		WithoutHistogram: im.noHistogram,
+		PercentMD:        im.percentMD,
+		SubscriberPolicy: im.subscribers.policy,
+		SubscriberBuffer: im.subscribers.buffer,
+		InputSelector:    im.input,
+	}
+
+	_, bandOK := im.maHigh.(*SMMA)
+	if _, ok := im.maLow.(*SMMA); ok && bandOK {
+		cfg.BandMA = MASmma
+	}
+	_, rmaOK := im.maHigh.(*RMA)
+	if _, ok := im.maLow.(*RMA); ok && rmaOK {
+		cfg.BandMA = MARma
+	}
+	if _, ok := im.maMid.(*ZLEMA); ok {
+		cfg.MidMA = MAZlema
+	}
+	if m, ok := im.maMid.(*MultiEMA); ok {
+		cfg.MidMA = MAMultiEMA
+		cfg.MidDepth = m.Depth()
+	}
+	if _, ok := im.maSignal.(*SMA); ok {
+		cfg.SignalMA = MASma
+	}
+
+	if w := im.weights; w != nil {
+		cfg.PriceWeights = &PriceWeights{w.open, w.high, w.low, w.close}
+	}
+	if im.shSmoothing != nil {
+		cfg.HistogramSmoothing = im.shSmoothing.length
+	}
+	if im.shStats != nil {
+		cfg.SHZScoreWindow = im.shStats.window
+	}
+	if b := im.boost; b != nil {
+		cfg.ExtremeBoost = &ExtremeBoost{b.factor, b.multiple}
+	}
+	if im.minMax != nil {
+		cfg.PersistentMinMax = true
+		cfg.MinMaxWindow = im.minMax.window
+	}
+	if im.precision > 0 {
+		cfg.OutputPrecision = int(math.Round(math.Log10(im.precision)))
+	}
+	return cfg
+}
+
+// equal compares two configurations, including the settings they point to
+func (cfg Config) equal(other Config) bool {
+	a, b := cfg, other
+	a.PriceWeights, b.PriceWeights = nil, nil
+	a.ExtremeBoost, b.ExtremeBoost = nil, nil
+	return a == b && equalPointee(cfg.PriceWeights, other.PriceWeights) &&
+		equalPointee(cfg.ExtremeBoost, other.ExtremeBoost)
+}
+
+// NewFromConfig creates an indicator from a configuration, validating it
+// like NewImpulseMACDWithOptions
+func NewFromConfig(cfg Config) (*ImpulseMACD, error) {
+	opts, err := cfg.options()
+	if err != nil {
+		return nil, err
+	}
+	return NewImpulseMACDWithOptions(cfg.LengthMA, cfg.LengthSignal, opts...)
+}
+
+// options translates the configuration into the equivalent options
+func (cfg Config) options() ([]Option, error) {
+	for _, ma := range []struct {
+		line       string
+		got, build MAType
+	}{
+		{"band", cfg.BandMA, MASmma},
+		{"mid", cfg.MidMA, MAZlema},
+		{"signal", cfg.SignalMA, MASma},
+	} {
+		if ma.got != "" && ma.got != ma.build && (ma.line != "band" || ma.got != MARma) &&
+			(ma.line != "mid" || ma.got != MAMultiEMA) {
+			return nil, fmt.Errorf("imacd: unsupported %s moving average %q", ma.line, ma.got)
+		}
+	}
+
+	var opts []Option
+	if cfg.BandLength != 0 {
+		opts = append(opts, WithBandLength(cfg.BandLength))
+	}
+	if cfg.BandMA == MARma {
+		opts = append(opts, WithTradingViewRMA())
+	}
+	if cfg.MidMA == MAMultiEMA {
+		opts = append(opts, WithMidDepth(cfg.MidDepth))
+	}
+	if w := cfg.PriceWeights; w != nil {
+		opts = append(opts, WithPriceWeights(w.Open, w.High, w.Low, w.Close))
+	}
+	if cfg.LogPrice {
+		opts = append(opts, WithLogPrice())
+	}
+	if cfg.MaxHistory != 0 {
+		opts = append(opts, WithMaxHistory(cfg.MaxHistory))
+	}
+	if cfg.HistogramSmoothing != 0 {
+		opts = append(opts, WithHistogramSmoothing(cfg.HistogramSmoothing))
+	}
+	if cfg.SHZScoreWindow != 0 {
+		opts = append(opts, WithSHZScore(cfg.SHZScoreWindow))
+	}
+	if cfg.PersistentMinMax {
+		opts = append(opts, WithPersistentMinMax(cfg.MinMaxWindow))
+	}
+	if cfg.SampleStatistics {
+		opts = append(opts, WithSampleStatistics(true))
+	}
+	if b := cfg.ExtremeBoost; b != nil {
+		opts = append(opts, WithExtremeBoost(b.Factor, b.Multiple))
+	}
+	if cfg.PercentMD {
+		opts = append(opts, WithPercentMD())
+	}
+	if cfg.WithoutHistogram {
+		opts = append(opts, WithoutHistogram())
+	}
+	if cfg.LatestForming {
+		opts = append(opts, WithLatestIncludesForming(true))
+	}
+	if cfg.OutputPrecision != 0 {
+		opts = append(opts, WithOutputPrecision(cfg.OutputPrecision))
+	}
+	if cfg.CrossDebounce != 0 {
+		opts = append(opts, WithCrossDebounce(cfg.CrossDebounce))
+	}
+	if cfg.RetainSources {
+		opts = append(opts, WithRetainSources())
+	}
+	if cfg.RetainBands {
+		opts = append(opts, WithRetainBands())
+	}
+	if cfg.RetainInputs {
+		opts = append(opts, WithRetainInputs())
+	}
+	if cfg.NeutralBand != 0 {
+		opts = append(opts, WithNeutralBand(cfg.NeutralBand))
+	}
+	if cfg.ColorEpsilon != 0 {
+		opts = append(opts, WithColorEpsilon(cfg.ColorEpsilon))
+	}
+	if cfg.ColorSource != ColorFromSource {
+		opts = append(opts, WithColorSource(cfg.ColorSource))
+	}
+	if cfg.Explain {
+		opts = append(opts, WithExplain(true))
+	}
+	if cfg.TimeDecay != 0 {
+		opts = append(opts, WithTimeDecay(cfg.TimeDecay))
+	}
+	if cfg.TimestampPolicy != TimestampAllow {
+		opts = append(opts, WithTimestampPolicy(cfg.TimestampPolicy))
+	}
+	if cfg.GradedColor {
+		opts = append(opts, WithGradedColor())
+	}
+	if cfg.SubscriberPolicy != SubscriberDrop || cfg.SubscriberBuffer != 0 {
+		opts = append(opts, WithSubscriberPolicy(cfg.SubscriberPolicy, cfg.SubscriberBuffer))
+	}
+	if cfg.InputSelector != InputTrade {
+		opts = append(opts, WithInputSelector(cfg.InputSelector))
+	}
+	return opts, nil
+}
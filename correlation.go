@@ -0,0 +1,44 @@
+package imacd
+
+import "math"
+
+// HistogramPriceCorrelation returns the Pearson correlation between the
+// histogram and the simple returns of the source price over the last window
+// bars, computed in two passes around the means for numerical stability.
+// ok is false without WithRetainSources, with fewer than window+1 retained
+// bars, or when either series is constant over the window.
+func (im *ImpulseMACD) HistogramPriceCorrelation(window int) (float64, bool) {
+	n := len(im.sources)
+	if !im.retainSources || window < 2 || n < window+1 || len(im.values) != n {
+		return 0, false
+	}
+
+	returns := make([]float64, window)
+	hist := make([]float64, window)
+	var meanR, meanH float64
+	for i := range window {
+		j := n - window + i
+		prev := im.sources[j-1]
+		if prev == 0 {
+			return 0, false
+		}
+		returns[i] = im.sources[j]/prev - 1
+		hist[i] = im.values[j].SH
+		meanR += returns[i]
+		meanH += hist[i]
+	}
+	meanR /= float64(window)
+	meanH /= float64(window)
+
+	var cov, varR, varH float64
+	for i := range window {
+		dr, dh := returns[i]-meanR, hist[i]-meanH
+		cov += dr * dh
+		varR += dr * dr
+		varH += dh * dh
+	}
+	if varR == 0 || varH == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varR*varH), true
+}
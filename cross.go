@@ -0,0 +1,214 @@
+package imacd
+
+import "time"
+
+// CrossType identifies a crossover between the MD and signal lines
+type CrossType int
+
+const (
+	CrossNone CrossType = iota // No crossover
+	CrossUp                    // MD crossed above the signal
+	CrossDown                  // MD crossed below the signal
+)
+
+// String returns the name of the cross type
+func (c CrossType) String() string {
+	switch c {
+	case CrossUp:
+		return "up"
+	case CrossDown:
+		return "down"
+	default:
+		return "none"
+	}
+}
+
+// crossBetween classifies the MD/SB crossover from prev to cur. Touching the
+// signal line does not count as a cross until MD moves through it.
+//
+// MD is exactly 0 while the mid line is inside the channel, and on such a
+// plateau SB decays towards 0, so the sign of MD-SB only reflects rounding
+// residue. Crosses are therefore handled explicitly around plateaus:
+//
+//   - between two bars with MD 0 no cross is reported
+//   - entering a plateau is an ordinary cross test
+//   - the bar leaving a plateau is compared with the first bar of the
+//     plateau, where MD-SB still had a clear sign, instead of with the
+//     previous bar
+//
+// crossBetween itself is the ordinary test; crossAt and crossState apply the
+// plateau rules.
+func crossBetween(prev, cur ImpulseValue) CrossType {
+	before := prev.MD - prev.SB
+	after := cur.MD - cur.SB
+	if before <= 0 && after > 0 {
+		return CrossUp
+	}
+	if before >= 0 && after < 0 {
+		return CrossDown
+	}
+	return CrossNone
+}
+
+// crossAt classifies the crossover into values[i], applying the plateau
+// rules of crossBetween; i must be at least 1
+func crossAt(values []ImpulseValue, i int) CrossType {
+	prev, cur := values[i-1], values[i]
+	if prev.MD == 0 {
+		if cur.MD == 0 {
+			return CrossNone
+		}
+		start := i - 1
+		for start > 0 && values[start-1].MD == 0 {
+			start--
+		}
+		prev = values[start]
+	}
+	return crossBetween(prev, cur)
+}
+
+// LastCrossInfo scans the calculated values backwards for the most recent
+// MD/SB crossover and reports its type and how many bars ago it occurred,
+// with 0 meaning the latest bar. ok is false when no cross exists in history.
+func (im *ImpulseMACD) LastCrossInfo() (cross CrossType, barsAgo int, ok bool) {
+	for i := len(im.values) - 1; i > 0; i-- {
+		if c := crossAt(im.values, i); c != CrossNone {
+			return c, len(im.values) - 1 - i, true
+		}
+	}
+	return CrossNone, 0, false
+}
+
+// FirstCrossAfterWarmup scans the stored values from the first valid one for
+// the earliest MD/SB crossover and returns its type and index into
+// GetValues. A cross counts once both of its bars are valid, so the seeded
+// values before warmup never produce it. ok is false when no such cross
+// exists; with a history limit only the retained values are scanned.
+func (im *ImpulseMACD) FirstCrossAfterWarmup() (cross CrossType, index int, ok bool) {
+	for i := 1; i < len(im.values); i++ {
+		if !im.values[i-1].Valid {
+			continue
+		}
+		if c := crossAt(im.values, i); c != CrossNone {
+			return c, i, true
+		}
+	}
+	return CrossNone, 0, false
+}
+
+// crossState tracks the crossovers reported while updating
+type crossState struct {
+	debounce int
+	handlers []func(CrossType, ImpulseValue)
+
+	last CrossType
+	// Bar count at the last reported cross per direction, 0 when none
+	lastAt [3]int
+
+	// First value of the current MD == 0 plateau, valid when inPlateau
+	plateauStart ImpulseValue
+	inPlateau    bool
+}
+
+// observe reports the cross between the previous and the newly appended
+// value unless it is suppressed by the debounce
+func (c *crossState) observe(im *ImpulseMACD, prev, cur ImpulseValue) {
+	before := prev
+	switch {
+	case cur.MD == 0 && prev.MD == 0:
+		if !c.inPlateau {
+			c.plateauStart, c.inPlateau = prev, true
+		}
+		return
+	case cur.MD == 0:
+		c.plateauStart, c.inPlateau = cur, true
+	case prev.MD == 0:
+		if c.inPlateau {
+			before = c.plateauStart
+		}
+		c.inPlateau = false
+	}
+
+	cross := crossBetween(before, cur)
+	if cross == CrossNone {
+		return
+	}
+	if at := c.lastAt[cross]; c.debounce > 0 && at > 0 && im.count-at < c.debounce {
+		return
+	}
+
+	c.last = cross
+	c.lastAt[cross] = im.count
+	for _, fn := range c.handlers {
+		fn(cross, cur)
+	}
+}
+
+func (c *crossState) reset() {
+	c.last = CrossNone
+	c.lastAt = [3]int{}
+	c.plateauStart, c.inPlateau = ImpulseValue{}, false
+}
+
+// OnCross registers a handler called from Update whenever a MD/SB crossover
+// is reported, after the value has been stored. Handlers may read the
+// indicator; mutating it from a handler panics.
+func (im *ImpulseMACD) OnCross(fn func(CrossType, ImpulseValue)) {
+	im.crosses.handlers = append(im.crosses.handlers, fn)
+}
+
+// LastCross returns the most recent crossover reported by Update, or
+// CrossNone. Unlike LastCrossInfo it respects WithCrossDebounce.
+func (im *ImpulseMACD) LastCross() CrossType {
+	return im.crosses.last
+}
+
+// CrossEvent is an MD/SB crossover at a bar of an input series
+type CrossEvent struct {
+	Index int // Index of the bar in the input
+	Type  CrossType
+	MD    float64
+	SB    float64
+	Close float64 // Close of the bar
+	Time  time.Time
+}
+
+// CrossEvents runs the bars through a fresh indicator with the same
+// configuration and returns every MD/SB crossover, oldest first, with the
+// index and close of its bar, for matching crosses against an order log.
+// Crosses follow the same rules as LastCrossInfo; the cross debounce applies
+// to OnCross handlers only and does not filter them. Bars rejected by the
+// timestamp policy produce no value and are skipped, so a cross is measured
+// against the previous accepted bar. Under TimestampMerge a merged bar
+// replaces the bar it was merged into, along with any cross reported there.
+// The receiver is not updated, and custom moving averages fall back to the
+// default ones.
+func (im *ImpulseMACD) CrossEvents(bars []PriceBar) []CrossEvent {
+	calc := newFromConfigOrDefault(im.Config(), im)
+	calc.maxHistory = 0
+	var events []CrossEvent
+	var values []ImpulseValue
+	var indices []int
+	for i, bar := range bars {
+		count := calc.count
+		v, err := calc.updateTimed(bar)
+		if err != nil {
+			continue
+		}
+		if calc.count == count && len(values) > 0 {
+			last := len(values) - 1
+			if n := len(events); n > 0 && events[n-1].Index == indices[last] {
+				events = events[:n-1]
+			}
+			values, indices = values[:last], indices[:last]
+		}
+		values, indices = append(values, v), append(indices, i)
+		if len(values) < 2 {
+			continue
+		}
+		if c := crossAt(values, len(values)-1); c != CrossNone {
+			events = append(events, CrossEvent{i, c, v.MD, v.SB, bar.Close, v.Timestamp})
+		}
+	}
+	return events
+}
@@ -0,0 +1,17 @@
+//go:build decimal
+
+package imacd
+
+import "github.com/shopspring/decimal"
+
+// ImpulseMACDDecimal runs the indicator in fixed-point decimal arithmetic,
+// avoiding float64 rounding in the band comparisons. Build with -tags decimal.
+// The go.mod of this module requires github.com/shopspring/decimal for this
+// file, so it is listed among the dependencies of every consumer, but it is
+// only compiled into builds using the tag.
+type ImpulseMACDDecimal = ImpulseMACDOf[decimal.Decimal]
+
+// NewImpulseMACDDecimal creates a new decimal Impulse MACD indicator
+func NewImpulseMACDDecimal(lengthMA, lengthSignal int, opts ...OptionOf[decimal.Decimal]) *ImpulseMACDDecimal {
+	return NewImpulseMACDOf(lengthMA, lengthSignal, decimal.NewFromInt, opts...)
+}
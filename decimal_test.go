@@ -0,0 +1,53 @@
+//go:build decimal
+
+package imacd
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// TestDecimalBandBoundary pins the case the decimal backend exists for: on
+// a flat bar at 0.7 the float64 hlc3 rounds to just below the low band and
+// reads red, while in decimal it equals the band and stays orange
+func TestDecimalBandBoundary(t *testing.T) {
+	float := NewImpulseMACDFloat(2, 2)
+	dec := NewImpulseMACDDecimal(2, 2)
+	price := decimal.RequireFromString("0.7")
+	var fv ImpulseValueOf[Float]
+	var dv ImpulseValueOf[decimal.Decimal]
+	for range 2 {
+		fv = float.Update(0.7, 0.7, 0.7)
+		dv = dec.Update(price, price, price)
+	}
+	if fv.Color != ColorRed {
+		t.Fatalf("float64 color = %v, want %v", fv.Color, ColorRed)
+	}
+	if dv.Color != ColorOrange || !dv.MD.IsZero() {
+		t.Fatalf("decimal value = %+v, want orange with zero MD", dv)
+	}
+}
+
+func TestDecimalFirstBarOrange(t *testing.T) {
+	im := NewImpulseMACDDecimal(3, 2)
+	v := im.Update(decimal.NewFromInt(10), decimal.NewFromInt(9), decimal.NewFromInt(10))
+	if v.Color != ColorOrange {
+		t.Fatalf("first bar color = %v, want %v", v.Color, ColorOrange)
+	}
+}
+
+func TestDecimalOptions(t *testing.T) {
+	im := NewImpulseMACDDecimal(2, 2,
+		WithColorEpsilonOf(decimal.NewFromInt(2)),
+		WithOutputPrecisionOf[decimal.Decimal](2))
+	im.Update(decimal.NewFromInt(10), decimal.NewFromInt(9), decimal.NewFromInt(10))
+	// hlc3 is 12, above the high band of 10.5 but within the epsilon of 2
+	v := im.Update(decimal.NewFromInt(11), decimal.NewFromInt(10), decimal.NewFromInt(15))
+	if v.Color != ColorGreen {
+		t.Fatalf("color = %v, want %v", v.Color, ColorGreen)
+	}
+	if !v.MD.Equal(v.MD.Round(2)) || !v.SB.Equal(v.SB.Round(2)) {
+		t.Fatalf("value %+v is not rounded to 2 decimals", v)
+	}
+}
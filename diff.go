@@ -0,0 +1,62 @@
+package imacd
+
+import "math"
+
+// Diff is a difference between two value series found by DiffValues
+type Diff struct {
+	Index     int
+	Field     string  // A float field of ImpulseValue, Color, HistColor or Len
+	A, B      float64 // The differing values; the lengths for Len, 0 for the colors
+	Magnitude float64 // |A - B|, 1 for the colors, +Inf when only one side is NaN
+}
+
+// DiffValues compares two value series index by index and reports every
+// numeric field differing by more than eps, and every color mismatch. When
+// the lengths differ, only the common prefix is compared and a final Len
+// diff at the shorter length records both lengths. Memory is only allocated
+// for the diffs found.
+func DiffValues(a, b []ImpulseValue, eps float64) []Diff {
+	var diffs []Diff
+	n := min(len(a), len(b))
+	for i := range n {
+		va, vb := &a[i], &b[i]
+		for _, f := range [...]struct {
+			name string
+			a, b float64
+		}{
+			{"MD", va.MD, vb.MD},
+			{"SB", va.SB, vb.SB},
+			{"SH", va.SH, vb.SH},
+			{"SHSmoothed", va.SHSmoothed, vb.SHSmoothed},
+			{"Intensity", va.Intensity, vb.Intensity},
+			{"NormalizedMD", va.NormalizedMD, vb.NormalizedMD},
+			{"BoostedMD", va.BoostedMD, vb.BoostedMD},
+			{"SHZScore", va.SHZScore, vb.SHZScore},
+		} {
+			if m := diffMagnitude(f.a, f.b); m > eps {
+				diffs = append(diffs, Diff{i, f.name, f.a, f.b, m})
+			}
+		}
+		if va.Color != vb.Color {
+			diffs = append(diffs, Diff{Index: i, Field: "Color", Magnitude: 1})
+		}
+		if va.HistColor != vb.HistColor {
+			diffs = append(diffs, Diff{Index: i, Field: "HistColor", Magnitude: 1})
+		}
+	}
+	if len(a) != len(b) {
+		diffs = append(diffs, Diff{n, "Len", float64(len(a)), float64(len(b)), math.Abs(float64(len(a) - len(b)))})
+	}
+	return diffs
+}
+
+// diffMagnitude returns |a-b|, treating two NaNs as equal
+func diffMagnitude(a, b float64) float64 {
+	switch nanA, nanB := math.IsNaN(a), math.IsNaN(b); {
+	case nanA && nanB:
+		return 0
+	case nanA || nanB:
+		return math.Inf(1)
+	}
+	return math.Abs(a - b)
+}
@@ -0,0 +1,93 @@
+package imacd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dumpValues is the number of latest values included by DumpState
+const dumpValues = 5
+
+// DumpState returns a readable description of the internal state for
+// debugging: the configuration, every sub-indicator's fields, the counts and
+// the last few values. The layout is stable, with one "key: value" per line
+// and floats printed at full precision, but it is diagnostic only and cannot
+// be restored.
+func (im *ImpulseMACD) DumpState() string {
+	var b strings.Builder
+	cfg := im.Config()
+	fmt.Fprintf(&b, "lengths: ma=%d signal=%d band=%d\n", im.lengthMA, im.lengthSignal, im.bandLen())
+	weights, boost := cfg.PriceWeights, cfg.ExtremeBoost
+	cfg.PriceWeights, cfg.ExtremeBoost = nil, nil // printed below, as the pointers are not stable
+	fmt.Fprintf(&b, "config: %+v\n", cfg)
+	if weights != nil {
+		fmt.Fprintf(&b, "price weights: %+v\n", *weights)
+	}
+	if boost != nil {
+		fmt.Fprintf(&b, "extreme boost: %+v\n", *boost)
+	}
+	fmt.Fprintf(&b, "count: %d\n", im.count)
+	fmt.Fprintf(&b, "warmed up: %t (min bars %d)\n", im.IsWarmedUp(), im.MinBars())
+	if !im.lastTime.IsZero() {
+		fmt.Fprintf(&b, "last time: %s\n", im.lastTime.Format("2006-01-02T15:04:05.999999999Z07:00"))
+	}
+
+	dumpMA(&b, "maHigh", im.maHigh)
+	dumpMA(&b, "maLow", im.maLow)
+	dumpMA(&b, "maMid", im.maMid)
+	dumpMA(&b, "maSignal", im.maSignal)
+	if im.shSmoothing != nil {
+		dumpMA(&b, "shSmoothing", im.shSmoothing)
+	}
+	if s := im.shStats; s != nil {
+		fmt.Fprintf(&b, "shStats: window=%d mean=%v m2=%v next=%d values=%v\n", s.window, s.mean, s.m2, s.next, s.values)
+	}
+	if s := im.minMax; s != nil {
+		fmt.Fprintf(&b, "minMax: window=%d min=%v max=%v seen=%t values=%v\n", s.window, s.min, s.max, s.seen, s.values)
+	}
+
+	fmt.Fprintf(&b, "crosses: last=%s lastAt=%v inPlateau=%t\n", im.crosses.last, im.crosses.lastAt, im.crosses.inPlateau)
+	fmt.Fprintf(&b, "checkpoints: %d\n", len(im.checkpoints))
+	if im.hasForming {
+		fmt.Fprintf(&b, "forming: %s\n", dumpValue(im.forming))
+	}
+
+	start := max(0, len(im.values)-dumpValues)
+	fmt.Fprintf(&b, "values: %d stored, last %d\n", len(im.values), len(im.values)-start)
+	for i := start; i < len(im.values); i++ {
+		fmt.Fprintf(&b, "  [%d] %s\n", i, dumpValue(im.values[i]))
+	}
+	return b.String()
+}
+
+func dumpMA(b *strings.Builder, name string, ma MovingAverage) {
+	switch ma := ma.(type) {
+	case *SMMA:
+		fmt.Fprintf(b, "%s: SMMA length=%d value=%v isInit=%t count=%d\n", name, ma.length, ma.value, ma.isInit, ma.count)
+	case *RMA:
+		fmt.Fprintf(b, "%s: RMA length=%d value=%v count=%d window=%v\n", name, ma.length, ma.value, ma.count, ma.window)
+	case *EMA:
+		fmt.Fprintf(b, "%s: EMA length=%d value=%v isInit=%t count=%d\n", name, ma.length, ma.value, ma.isInit, ma.count)
+	case *ZLEMA:
+		fmt.Fprintf(b, "%s: ZLEMA length=%d value=%v\n", name, ma.length, ma.value)
+		dumpMA(b, "  ema1", ma.ema1)
+		dumpMA(b, "  ema2", ma.ema2)
+	case *MultiEMA:
+		fmt.Fprintf(b, "%s: MultiEMA length=%d depth=%d value=%v\n", name, ma.length, len(ma.emas), ma.value)
+		for k, e := range ma.emas {
+			dumpMA(b, fmt.Sprintf("  ema%d", k+1), e)
+		}
+	case *SMA:
+		fmt.Fprintf(b, "%s: SMA length=%d value=%v sum=%v count=%d window=%v\n", name, ma.length, ma.value, ma.sum, ma.count, ma.window.Values())
+	default:
+		fmt.Fprintf(b, "%s: custom %T value=%v\n", name, ma, ma.Value())
+	}
+}
+
+func dumpValue(v ImpulseValue) string {
+	s := fmt.Sprintf("MD=%v SB=%v SH=%v color=%s valid=%t", v.MD, v.SB, v.SH, v.Color, v.Valid)
+	if !v.Timestamp.IsZero() {
+		s += " time=" + v.Timestamp.Format("2006-01-02T15:04:05.999999999Z07:00")
+	}
+	return s
+}
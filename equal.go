@@ -0,0 +1,115 @@
+package imacd
+
+import (
+	"math"
+	"slices"
+)
+
+// Equal reports whether two indicators have the same configuration, internal
+// state and calculated values, so they produce identical results from here
+// on. Registered handlers, clocks and active checkpoints are not compared, MD
+// transforms only by whether one is set, and custom moving averages are only
+// equal to themselves.
+func (im *ImpulseMACD) Equal(other *ImpulseMACD) bool {
+	if im == other {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+
+	return im.lengthMA == other.lengthMA &&
+		im.lengthSignal == other.lengthSignal &&
+		im.maxHistory == other.maxHistory &&
+		im.logPrice == other.logPrice &&
+		im.precision == other.precision &&
+		im.crosses.debounce == other.crosses.debounce &&
+		im.retainSources == other.retainSources &&
+		im.retainBands == other.retainBands &&
+		im.retainInputs == other.retainInputs &&
+		im.neutralBand == other.neutralBand &&
+		im.colorEps == other.colorEps &&
+		im.colorSource == other.colorSource &&
+		im.explain == other.explain &&
+		im.bandLength == other.bandLength &&
+		im.tvRMA == other.tvRMA &&
+		im.gradedColor == other.gradedColor &&
+		im.sampleStats == other.sampleStats &&
+		im.noHistogram == other.noHistogram &&
+		im.percentMD == other.percentMD &&
+		(im.mdTransform == nil) == (other.mdTransform == nil) &&
+		im.latestForming == other.latestForming &&
+		im.hasForming == other.hasForming &&
+		im.forming == other.forming &&
+		im.timeDecay == other.timeDecay &&
+		im.timestamps == other.timestamps &&
+		im.input == other.input &&
+		im.subscribers.policy == other.subscribers.policy &&
+		im.subscribers.buffer == other.subscribers.buffer &&
+		im.lastTime.Equal(other.lastTime) &&
+		equalPointee(im.weights, other.weights) &&
+		equalPointee(im.boost, other.boost) &&
+		equalEMA(im.shSmoothing, other.shSmoothing) &&
+		im.shStats.equal(other.shStats) &&
+		im.minMax.equal(other.minMax) &&
+		equalMA(im.maHigh, other.maHigh) &&
+		equalMA(im.maLow, other.maLow) &&
+		equalMA(im.maMid, other.maMid) &&
+		equalMA(im.maSignal, other.maSignal) &&
+		im.count == other.count &&
+		im.crosses.last == other.crosses.last &&
+		im.crosses.lastAt == other.crosses.lastAt &&
+		im.crosses.plateauStart == other.crosses.plateauStart &&
+		im.crosses.inPlateau == other.crosses.inPlateau &&
+		slices.Equal(im.values, other.values) &&
+		slices.Equal(im.sources, other.sources) &&
+		slices.Equal(im.bands, other.bands) &&
+		slices.EqualFunc(im.inputs, other.inputs, barInput.equal)
+}
+
+func equalPointee[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// equalMA compares the state of two moving averages, which must be of the
+// same built-in type or the same custom instance
+func equalMA(a, b MovingAverage) bool {
+	switch a := a.(type) {
+	case *SMMA:
+		b, ok := b.(*SMMA)
+		return ok && a.length == b.length && a.value == b.value && a.isInit == b.isInit &&
+			a.count == b.count && a.decay == b.decay
+	case *RMA:
+		b, ok := b.(*RMA)
+		return ok && a.length == b.length && a.count == b.count && slices.Equal(a.window, b.window) &&
+			(a.value == b.value || math.IsNaN(a.value) && math.IsNaN(b.value))
+	case *EMA:
+		b, ok := b.(*EMA)
+		return ok && equalEMA(a, b)
+	case *ZLEMA:
+		b, ok := b.(*ZLEMA)
+		return ok && a.length == b.length && a.value == b.value &&
+			equalEMA(a.ema1, b.ema1) && equalEMA(a.ema2, b.ema2)
+	case *MultiEMA:
+		b, ok := b.(*MultiEMA)
+		return ok && a.length == b.length && a.value == b.value &&
+			slices.EqualFunc(a.emas, b.emas, equalEMA)
+	case *SMA:
+		b, ok := b.(*SMA)
+		return ok && a.length == b.length && a.sum == b.sum && a.value == b.value &&
+			a.count == b.count && slices.Equal(a.window.Values(), b.window.Values())
+	default:
+		return a == b
+	}
+}
+
+func equalEMA(a, b *EMA) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.length == b.length && a.multiplier == b.multiplier &&
+		a.value == b.value && a.isInit == b.isInit && a.count == b.count && a.decay == b.decay
+}
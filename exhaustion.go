@@ -0,0 +1,40 @@
+package imacd
+
+import "math"
+
+// IsExhausting reports whether the histogram is fading after an extreme. With
+// the last three histogram values a, b and c (c the latest) it is true when:
+//
+//   - all three are non-zero with the same sign, so the rule is sign-aware
+//     and works for both up and down legs
+//   - their magnitudes strictly decrease, |a| > |b| > |c|
+//   - a is the largest magnitude of the current leg, scanning back until the
+//     histogram changes sign or reaches zero
+//
+// It is false with fewer than three values.
+func (im *ImpulseMACD) IsExhausting() bool {
+	n := len(im.values)
+	if n < 3 {
+		return false
+	}
+
+	a, b, c := im.values[n-3].SH, im.values[n-2].SH, im.values[n-1].SH
+	sign := math.Copysign(1, c)
+	if c == 0 || a*sign <= 0 || b*sign <= 0 {
+		return false
+	}
+	if !(math.Abs(a) > math.Abs(b) && math.Abs(b) > math.Abs(c)) {
+		return false
+	}
+
+	for i := n - 4; i >= 0; i-- {
+		sh := im.values[i].SH
+		if sh*sign <= 0 {
+			break
+		}
+		if math.Abs(sh) > math.Abs(a) {
+			return false
+		}
+	}
+	return true
+}
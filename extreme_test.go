@@ -0,0 +1,55 @@
+package imacd
+
+import (
+	"math"
+	"testing"
+)
+
+// TestExtremeScales feeds bars at the edges of the float64 range and checks
+// that every output stays finite, as the overflow guards in the source,
+// SMMA, SMA and ZLEMA promise
+func TestExtremeScales(t *testing.T) {
+	const max = math.MaxFloat64
+	trend := func(from, step float64, n int) []PriceBar {
+		bars := make([]PriceBar, n)
+		for i := range bars {
+			x := math.Min(from*math.Pow(step, float64(i)), max)
+			bars[i] = PriceBar{High: x, Low: x * 0.99, Close: x * 0.995}
+		}
+		return bars
+	}
+	tests := []struct {
+		name string
+		bars []PriceBar
+	}{
+		{"1e300", trend(1e300, 1.01, 60)},
+		{"1e-300", trend(1e-300, 0.99, 60)},
+		{"subnormal", trend(5e-320, 0.9, 60)},
+		{"max_float", []PriceBar{
+			{High: max, Low: max, Close: max},
+			{High: max, Low: max / 2, Close: max},
+			{High: max, Low: max / 4, Close: max / 2},
+			{High: max / 8, Low: max / 16, Close: max / 8},
+			{High: max, Low: max, Close: max},
+		}},
+		// The mid line extrapolates a trend, so 2*ema1-ema2 passes the limit
+		{"trend_to_max", trend(max/1e6, 4, 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, opts := range [][]Option{nil, {WithTradingViewRMA()}, {WithPercentMD(), WithSHZScore(5)}, {WithMidDepth(3)}, {WithLogPrice()}} {
+				im := newIndicator(t, opts...)
+				for i, bar := range tt.bars {
+					v := im.Update(bar.High, bar.Low, bar.Close)
+					hi, lo, mid, _ := im.CurrentBands()
+					for _, out := range [...]float64{v.MD, v.SB, v.SH, v.SHZScore, hi, lo, mid} {
+						if math.IsNaN(out) || math.IsInf(out, 0) {
+							t.Fatalf("bar %d (%v, %v, %v): non-finite output in %+v, bands %v %v %v",
+								i, bar.High, bar.Low, bar.Close, v, hi, lo, mid)
+						}
+					}
+				}
+			}
+		})
+	}
+}
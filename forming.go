@@ -0,0 +1,41 @@
+package imacd
+
+import "time"
+
+// UpdateForming computes the value of a bar that is still forming without
+// committing it: the state is left as it was, OnCross handlers are not
+// called and nothing is added to the history. Call it as often as the bar
+// changes, then commit the final bar with Update or one of its variants,
+// which discards the forming value. It uses checkpoints, so it returns
+// ErrCustomMovingAverage when a sub-indicator does not support them.
+func (im *ImpulseMACD) UpdateForming(high, low, close float64) (ImpulseValue, error) {
+	if err := im.pushCheckpoint(false); err != nil {
+		return ImpulseValue{}, err
+	}
+	im.previewing = true
+	value := im.update(time.Time{}, high, low, close, im.source(close, high, low, close))
+	im.previewing = false
+	if err := im.popCheckpoint(); err != nil {
+		return ImpulseValue{}, err
+	}
+
+	im.forming, im.hasForming = value, true
+	return value, nil
+}
+
+// Forming returns the latest value computed by UpdateForming, with ok false
+// when none was computed since the last committed update
+func (im *ImpulseMACD) Forming() (value ImpulseValue, ok bool) {
+	return im.forming, im.hasForming
+}
+
+// WithLatestIncludesForming makes GetLatest return the forming value from
+// UpdateForming, when there is one, instead of the latest committed value.
+// By default GetLatest only reflects committed bars; GetValues and the other
+// history accessors never include the forming bar.
+func WithLatestIncludesForming(include bool) Option {
+	return func(im *ImpulseMACD) error {
+		im.latestForming = include
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package imacd
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestUpdateFormingCycle previews each bar a few times before committing it,
+// with and without WithLatestIncludesForming, and checks that the previews
+// leave the state alone and that only GetLatest ever shows them
+func TestUpdateFormingCycle(t *testing.T) {
+	bars := randomBars(rand.New(rand.NewPCG(6, 158)), 50)
+	for _, include := range []bool{false, true} {
+		im := newIndicator(t, WithLatestIncludesForming(include), WithHistogramSmoothing(3))
+		plain := newIndicator(t, WithLatestIncludesForming(include), WithHistogramSmoothing(3))
+		var crosses int
+		im.OnCross(func(CrossType, ImpulseValue) { crosses++ })
+		var want int
+		plain.OnCross(func(CrossType, ImpulseValue) { want++ })
+
+		for i, bar := range bars {
+			var preview ImpulseValue
+			for _, close := range []float64{bar.Low, (bar.Low + bar.Close) / 2, bar.Close} {
+				v, err := im.UpdateForming(bar.High, bar.Low, close)
+				if err != nil {
+					t.Fatal(err)
+				}
+				preview = v
+			}
+			if v, ok := im.Forming(); !ok || v != preview {
+				t.Fatalf("bar %d: Forming = %+v %t, want the last preview", i, v, ok)
+			}
+			if len(im.GetValues()) != i || !slices.Equal(im.GetValues(), plain.GetValues()) {
+				t.Fatalf("bar %d: a preview reached the history", i)
+			}
+			latest := im.GetLatest()
+			switch {
+			case include && (latest == nil || *latest != preview):
+				t.Fatalf("bar %d: GetLatest %v, want the forming value", i, latest)
+			case !include && i > 0 && *latest != *plain.GetLatest():
+				t.Fatalf("bar %d: GetLatest %+v, want the committed value", i, *latest)
+			case !include && i == 0 && latest != nil:
+				t.Fatalf("GetLatest before any commit = %+v, want nil", *latest)
+			}
+
+			// The final preview saw the final bar, so the commit must match it
+			got := im.Update(bar.High, bar.Low, bar.Close)
+			exp := plain.Update(bar.High, bar.Low, bar.Close)
+			if got != exp || got != preview {
+				t.Fatalf("bar %d: committed %+v, want %+v from the plain indicator and the preview", i, got, exp)
+			}
+			if _, ok := im.Forming(); ok {
+				t.Fatalf("bar %d: forming value kept after the commit", i)
+			}
+			if *im.GetLatest() != exp {
+				t.Fatalf("bar %d: GetLatest after the commit is not the committed value", i)
+			}
+		}
+		if crosses != want || !im.Equal(plain) {
+			t.Fatalf("include=%t: %d crosses, want %d, or the states differ", include, crosses, want)
+		}
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/felixdotgo/imacd
 
 go 1.23.0
+
+require github.com/shopspring/decimal v1.4.0
@@ -0,0 +1,194 @@
+package imacd
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// StateHash returns a fingerprint of the configuration and the state that
+// drives future outputs, for use as a cache or dedup key. It is FNV-1a over a
+// fixed little-endian encoding of the same fields Equal compares, except the
+// stored history (values, sources, bands and inputs), so Equal indicators
+// always hash identically and the hash does not grow with the history.
+// Indicators differing only in history can collide. The hash is stable across
+// runs, platforms and builds of the same version of this package; adding
+// configuration or state in a later version may change it. Custom moving
+// averages contribute their type and current value.
+func (im *ImpulseMACD) StateHash() uint64 {
+	h := stateHasher{fnv.New64a()}
+
+	cfg, err := json.Marshal(im.Config())
+	if err != nil {
+		panic("imacd: config not encodable: " + err.Error())
+	}
+	h.bytes(cfg)
+	h.bool(im.mdTransform != nil)
+
+	h.int(im.count)
+	h.time(im.lastTime)
+	h.int(int(im.crosses.last))
+	for _, at := range im.crosses.lastAt {
+		h.int(at)
+	}
+	h.value(im.crosses.plateauStart)
+	h.bool(im.crosses.inPlateau)
+	h.bool(im.hasForming)
+	h.value(im.forming)
+
+	for _, ma := range []MovingAverage{im.maHigh, im.maLow, im.maMid, im.maSignal} {
+		h.ma(ma)
+	}
+	h.bool(im.shSmoothing != nil)
+	if im.shSmoothing != nil {
+		h.ma(im.shSmoothing)
+	}
+	h.bool(im.shStats != nil)
+	if s := im.shStats; s != nil {
+		h.int(s.window)
+		h.int(s.next)
+		h.float(s.mean)
+		h.float(s.m2)
+		h.bool(s.sample)
+		h.floats(s.values)
+	}
+	h.bool(im.minMax != nil)
+	if s := im.minMax; s != nil {
+		h.int(s.window)
+		h.float(s.min)
+		h.float(s.max)
+		h.bool(s.seen)
+		h.floats(s.values)
+	}
+	return h.Sum64()
+}
+
+// stateHasher writes fields to the hash in a fixed encoding. Floats are
+// canonicalized the way Equal compares them: -0 hashes as 0 and every NaN
+// alike.
+type stateHasher struct {
+	hash.Hash64
+}
+
+func (h stateHasher) uint(n uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	h.Write(buf[:])
+}
+
+func (h stateHasher) int(n int) {
+	h.uint(uint64(n))
+}
+
+func (h stateHasher) bool(b bool) {
+	if b {
+		h.uint(1)
+	} else {
+		h.uint(0)
+	}
+}
+
+func (h stateHasher) float(f float64) {
+	switch {
+	case f == 0:
+		h.uint(0)
+	case math.IsNaN(f):
+		h.uint(math.Float64bits(math.NaN()))
+	default:
+		h.uint(math.Float64bits(f))
+	}
+}
+
+func (h stateHasher) floats(fs []float64) {
+	h.int(len(fs))
+	for _, f := range fs {
+		h.float(f)
+	}
+}
+
+func (h stateHasher) bytes(b []byte) {
+	h.int(len(b))
+	h.Write(b)
+}
+
+func (h stateHasher) time(t time.Time) {
+	h.bool(t.IsZero())
+	if !t.IsZero() {
+		h.uint(uint64(t.UnixNano()))
+	}
+}
+
+func (h stateHasher) value(v ImpulseValue) {
+	for _, f := range [...]float64{v.MD, v.SB, v.SH, v.SHSmoothed, v.Intensity, v.NormalizedMD, v.BoostedMD, v.SHZScore} {
+		h.float(f)
+	}
+	h.bytes([]byte(v.Color))
+	h.bytes([]byte(v.HistColor))
+	h.bytes([]byte(v.Reason))
+	h.bool(v.Valid)
+	h.time(v.Timestamp)
+}
+
+func (h stateHasher) decay(d timeDecay) {
+	h.uint(uint64(d.halflife))
+	h.uint(uint64(d.elapsed))
+	h.bool(d.timed)
+}
+
+func (h stateHasher) ema(e *EMA) {
+	h.int(e.length)
+	h.float(e.value)
+	h.bool(e.isInit)
+	h.int(e.count)
+	h.decay(e.decay)
+}
+
+// ma hashes a moving average under a type tag, mirroring equalMA
+func (h stateHasher) ma(ma MovingAverage) {
+	switch ma := ma.(type) {
+	case *SMMA:
+		h.bytes([]byte("smma"))
+		h.int(ma.length)
+		h.float(ma.value)
+		h.bool(ma.isInit)
+		h.int(ma.count)
+		h.decay(ma.decay)
+	case *RMA:
+		h.bytes([]byte("rma"))
+		h.int(ma.length)
+		h.float(ma.value)
+		h.int(ma.count)
+		h.floats(ma.window)
+	case *EMA:
+		h.bytes([]byte("ema"))
+		h.ema(ma)
+	case *ZLEMA:
+		h.bytes([]byte("zlema"))
+		h.int(ma.length)
+		h.float(ma.value)
+		h.ema(ma.ema1)
+		h.ema(ma.ema2)
+	case *MultiEMA:
+		h.bytes([]byte("multi_ema"))
+		h.int(ma.length)
+		h.float(ma.value)
+		h.int(len(ma.emas))
+		for _, e := range ma.emas {
+			h.ema(e)
+		}
+	case *SMA:
+		h.bytes([]byte("sma"))
+		h.int(ma.length)
+		h.float(ma.sum)
+		h.float(ma.value)
+		h.int(ma.count)
+		h.floats(ma.window.Values())
+	default:
+		h.bytes(fmt.Appendf(nil, "%T", ma))
+		h.float(ma.Value())
+	}
+}
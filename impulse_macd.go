@@ -1,22 +1,143 @@
 package imacd
 
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"time"
+)
+
+// ErrCustomMovingAverage is returned by operations that need the internal
+// state of the built-in moving averages when a custom one is in use
+var ErrCustomMovingAverage = errors.New("imacd: operation requires the default moving averages")
+
+// ErrEmptyInput is returned by batch operations that need at least one bar
+var ErrEmptyInput = errors.New("imacd: no price bars given")
+
 // ImpulseMACD represents the Impulse MACD indicator
 type ImpulseMACD struct {
 	lengthMA     int
 	lengthSignal int
 
-	// Internal state for SMMA calculations
-	smmaHigh *SMMA
-	smmaLow  *SMMA
+	// Internal state for the high and low bands (SMMA by default)
+	maHigh MovingAverage
+	maLow  MovingAverage
 
-	// Internal state for ZLEMA calculation
-	zlema *ZLEMA
+	// Internal state for the mid line (ZLEMA by default)
+	maMid MovingAverage
 
-	// Internal state for signal SMA
-	signalSMA *SMA
+	// Internal state for the signal line (SMA by default)
+	maSignal MovingAverage
 
 	// Historical values for calculations
 	values []ImpulseValue
+
+	// Number of bars processed since construction or the last reset
+	count int
+
+	// Maximum number of values retained, 0 for unlimited
+	maxHistory int
+
+	// Smooth the mid line on log prices
+	logPrice bool
+
+	// Optional EMA applied to the histogram for SHSmoothed
+	shSmoothing *EMA
+	// Optional rolling statistics of the histogram for SHZScore
+	shStats *RollingStats
+
+	// Rounding factor (10^decimals) for emitted values, 0 for none
+	precision float64
+
+	// Reported MD/SB crossovers
+	crosses crossState
+
+	// Handlers registered with OnValue
+	valueHandlers []func(ImpulseValue)
+
+	// Channels returned by Subscribe, and whether WarmStart silences them
+	subscribers *broadcaster
+	muted       bool
+	// Set while OnCross and OnValue handlers run, see checkReentry
+	notifying bool
+
+	// Active checkpoints, innermost last
+	checkpoints []checkpoint
+
+	// Source price weights, nil for HLC3
+	weights *priceWeights
+
+	// Source prices aligned with values, kept with WithRetainSources
+	retainSources bool
+	sources       []float64
+
+	// Band and mid line values aligned with values, kept with WithRetainBands
+	retainBands bool
+	bands       []Bands
+
+	// Inputs of every bar aligned with values, kept with WithRetainInputs
+	retainInputs bool
+	inputs       []barInput
+
+	// Fraction of the band spread widening the channel for MD
+	neutralBand float64
+
+	// Length of the band SMMAs when different from lengthMA, 0 otherwise
+	bandLength int
+	// Bands use the TradingView compatible RMA instead of SMMA
+	tvRMA bool
+
+	// Populate ImpulseValue.Intensity
+	gradedColor bool
+	// Distance from a band within which the source counts as on the band
+	colorEps float64
+	// Price compared with the mid line and bands for the color
+	colorSource ColorSource
+	// Populate ImpulseValue.Reason
+	explain bool
+
+	// Range of MD for NormalizedMD, set with WithPersistentMinMax
+	minMax *minMaxScaler
+
+	// Use n-1 rather than n as the variance divisor
+	sampleStats bool
+
+	// Express MD as a percentage of the mid line
+	percentMD bool
+
+	// Applied to MD before the signal, set with WithMDTransform
+	mdTransform func(float64) float64
+
+	// Skip the histogram, leaving SH and SHSmoothed at 0
+	noHistogram bool
+
+	// MD amplification beyond the bands, set with WithExtremeBoost
+	boost *extremeBoost
+
+	// Value of the bar still forming, from UpdateForming
+	forming    ImpulseValue
+	hasForming bool
+	// GetLatest returns the forming value when there is one
+	latestForming bool
+	// Set while UpdateForming runs, so update leaves the history alone
+	previewing bool
+
+	// Price of a PriceBar feeding the mid line
+	input InputSelector
+
+	// Handling of repeated and earlier timestamps
+	timestamps TimestampPolicy
+	// The latest timed bar, merged so far, under TimestampMerge
+	mergeBar PriceBar
+
+	// Halflife of the time-decay weighting, 0 when disabled
+	timeDecay time.Duration
+	// Timestamp of the latest timed bar
+	lastTime time.Time
+	// Stamps untimed updates when set, see WithClock
+	clock Clock
 }
 
 // ImpulseValue represents a single calculation result
@@ -24,7 +145,92 @@ type ImpulseValue struct {
 	MD    float64 // Main difference
 	SB    float64 // Signal
 	SH    float64 // Histogram (MD - SB)
-	Color string  // Color indication
+	Color Color   // Color indication
+
+	// Whether the indicator was warmed up at this bar, see MinBars. Earlier
+	// values, including the fixed orange color of the first bar, are only
+	// seeds.
+	Valid bool
+
+	// EMA smoothed histogram, set with WithHistogramSmoothing
+	SHSmoothed float64
+
+	// Time of the bar, zero when updated without a timestamp
+	Timestamp time.Time
+
+	// Distance of the source beyond the band in band widths, set with
+	// WithGradedColor
+	Intensity float64
+
+	// MD mapped into [0, 1] against its observed range, set with
+	// WithPersistentMinMax
+	NormalizedMD float64
+
+	// MD amplified on extreme excursions, set with WithExtremeBoost
+	BoostedMD float64
+
+	// Histogram standardized over a rolling window, set with WithSHZScore
+	SHZScore float64
+
+	// Comparisons that decided the color, set with WithExplain
+	Reason string
+
+	// Histogram color from the sign of SH and its change, see histColor
+	HistColor Color
+}
+
+// Bands are the high band, low band and mid line of a bar
+type Bands struct {
+	High, Low, Mid float64
+}
+
+// Color is the bar color classification of an ImpulseValue, also used for
+// its histogram color
+type Color string
+
+// Bar colors, matching the TradingView indicator
+const (
+	ColorLime   Color = "lime"   // Source above the mid line and the high band
+	ColorGreen  Color = "green"  // Source above the mid line, inside the channel
+	ColorRed    Color = "red"    // Source below the mid line and the low band
+	ColorOrange Color = "orange" // Source below the mid line, inside the channel
+)
+
+// histColor classifies the histogram like a classic MACD chart, from its
+// sign and its change since the previous bar:
+//
+//	SH > 0, rising or flat   ColorLime
+//	SH > 0, falling          ColorGreen
+//	SH < 0, falling or flat  ColorRed
+//	SH < 0, rising           ColorOrange
+//
+// so the bright colors mark a strengthening move and the dim ones a fading
+// move. A zero histogram, as on the first bar or with WithoutHistogram, has
+// no color. Without a previous stored value the change is taken from zero.
+func histColor(prevSH, sh float64) Color {
+	switch {
+	case sh > 0 && sh >= prevSH:
+		return ColorLime
+	case sh > 0:
+		return ColorGreen
+	case sh < 0 && sh <= prevSH:
+		return ColorRed
+	case sh < 0:
+		return ColorOrange
+	default:
+		return ""
+	}
+}
+
+// MovingAverage is a streaming smoother used for the bands, the mid line and
+// the signal line. SMMA, ZLEMA, EMA and SMA all implement it.
+type MovingAverage interface {
+	// Update feeds a new value and returns the updated average
+	Update(value float64) float64
+	// Value returns the current average without updating it
+	Value() float64
+	// Reset clears all internal state
+	Reset()
 }
 
 // SMMA (Smoothed Moving Average) helper
@@ -32,6 +238,9 @@ type SMMA struct {
 	length int
 	value  float64
 	isInit bool
+	count  int
+	saved  []averageState
+	decay  timeDecay
 }
 
 // ZLEMA (Zero Lag EMA) helper
@@ -39,6 +248,8 @@ type ZLEMA struct {
 	length int
 	ema1   *EMA
 	ema2   *EMA
+	value  float64
+	saved  []float64
 }
 
 // EMA (Exponential Moving Average) helper
@@ -47,98 +258,466 @@ type EMA struct {
 	multiplier float64
 	value      float64
 	isInit     bool
+	count      int
+	saved      []averageState
+	decay      timeDecay
 }
 
 // SMA (Simple Moving Average) helper
 type SMA struct {
 	length int
-	values []float64
+	window *SlidingWindow[float64]
 	sum    float64
+	value  float64
+	count  int
+	saved  []smaState
 }
 
-// NewImpulseMACD creates a new Impulse MACD indicator
+// NewImpulseMACD creates a new Impulse MACD indicator. Lengths below 1 are
+// treated as 1; use NewImpulseMACDWithOptions to reject them instead.
 func NewImpulseMACD(lengthMA, lengthSignal int) *ImpulseMACD {
+	lengthMA, lengthSignal = max(lengthMA, 1), max(lengthSignal, 1)
 	return &ImpulseMACD{
 		lengthMA:     lengthMA,
 		lengthSignal: lengthSignal,
-		smmaHigh:     NewSMMA(lengthMA),
-		smmaLow:      NewSMMA(lengthMA),
-		zlema:        NewZLEMA(lengthMA),
-		signalSMA:    NewSMA(lengthSignal),
+		maHigh:       NewSMMA(lengthMA),
+		maLow:        NewSMMA(lengthMA),
+		maMid:        NewZLEMA(lengthMA),
+		maSignal:     NewSMA(lengthSignal),
 		values:       make([]ImpulseValue, 0),
+		subscribers:  &broadcaster{},
+	}
+}
+
+// NewImpulseMACDWithMAs creates a new Impulse MACD indicator from custom
+// moving averages for the high band, low band, mid line and signal line. The
+// lengths are taken from the built-in types among them, so warmup tracking,
+// Config and serialization see the lengths in use; lines built from other
+// types leave their length at 0.
+func NewImpulseMACDWithMAs(high, low, mid, signal MovingAverage) *ImpulseMACD {
+	im := &ImpulseMACD{
+		maHigh:      high,
+		maLow:       low,
+		maMid:       mid,
+		maSignal:    signal,
+		values:      make([]ImpulseValue, 0),
+		subscribers: &broadcaster{},
+	}
+
+	switch mid := mid.(type) {
+	case *ZLEMA:
+		im.lengthMA = mid.length
+	case *MultiEMA:
+		im.lengthMA = mid.length
+	}
+	if h, ok := high.(*SMMA); ok {
+		if l, ok := low.(*SMMA); ok && l.length == h.length {
+			if im.lengthMA == 0 {
+				im.lengthMA = h.length
+			} else if h.length != im.lengthMA {
+				im.bandLength = h.length
+			}
+		}
+	}
+	if s, ok := signal.(*SMA); ok {
+		im.lengthSignal = s.length
 	}
+	return im
+}
+
+// builtins returns the default sub-indicators, or ok=false when any of them
+// was replaced by a custom MovingAverage
+func (im *ImpulseMACD) builtins() (high, low *SMMA, mid *ZLEMA, signal *SMA, ok bool) {
+	high, okHigh := im.maHigh.(*SMMA)
+	low, okLow := im.maLow.(*SMMA)
+	mid, okMid := im.maMid.(*ZLEMA)
+	signal, okSignal := im.maSignal.(*SMA)
+	return high, low, mid, signal, okHigh && okLow && okMid && okSignal
 }
 
-// Update processes new price data (high, low, close)
+// setCounts sets the sample counts of the built-in sub-indicators when their
+// state is restored, as each of them sees one value per bar
+func setCounts(n int, high, low *SMMA, mid *ZLEMA, signal *SMA) {
+	high.count, low.count, signal.count = n, n, n
+	mid.ema1.count, mid.ema2.count = n, n
+}
+
+// Update processes new price data (high, low, close). With WithPriceWeights
+// the close is also used as the open; use UpdateOHLC to supply it.
 func (im *ImpulseMACD) Update(high, low, close float64) ImpulseValue {
-	// Calculate HLC3 (typical price)
-	hlc3 := (high + low + close) / 3.0
+	return im.UpdateOHLC(close, high, low, close)
+}
+
+// UpdateOHLC processes new price data including the open, which is only
+// used when WithPriceWeights gives it a weight
+func (im *ImpulseMACD) UpdateOHLC(open, high, low, close float64) ImpulseValue {
+	return im.update(time.Time{}, high, low, close, im.source(open, high, low, close))
+}
+
+// UpdateTypical processes a bar whose typical price was computed upstream:
+// typical feeds the mid line directly while high and low feed the bands. The
+// typical price is used as is, so WithPriceWeights does not apply to it;
+// WithLogPrice still does.
+func (im *ImpulseMACD) UpdateTypical(typical, high, low float64) ImpulseValue {
+	return im.update(time.Time{}, high, low, typical, typical)
+}
+
+// UpdateAt processes new price data (high, low, close) for the bar at t,
+// stamping the result with t. Timestamps must not go backwards; repeated
+// ones are handled per WithTimestampPolicy.
+func (im *ImpulseMACD) UpdateAt(t time.Time, high, low, close float64) (ImpulseValue, error) {
+	if im.count > 0 && t.Before(im.lastTime) {
+		return ImpulseValue{}, ErrOutOfOrder
+	}
+	return im.updateTimed(PriceBar{Open: close, High: high, Low: low, Close: close, Time: t})
+}
+
+// updateBar processes a single price bar, using its time when set. Bars out
+// of time order are processed as if no time had elapsed, unless the
+// timestamp policy rejects them, which yields a zero value.
+func (im *ImpulseMACD) updateBar(bar PriceBar) ImpulseValue {
+	value, _ := im.updateTimed(bar)
+	return value
+}
+
+// source calculates the source price, HLC3 (typical price) by default. When
+// the sum overflows for prices near the float64 limit, the terms are scaled
+// before adding them instead, and HLC3 is taken as an offset from the high,
+// which cannot round past the limit as the scaled sum can.
+func (im *ImpulseMACD) source(open, high, low, close float64) float64 {
+	if w := im.weights; w != nil {
+		src := (open*w.open + high*w.high + low*w.low + close*w.close) / w.total
+		if math.IsInf(src, 0) {
+			src = open*(w.open/w.total) + high*(w.high/w.total) + low*(w.low/w.total) + close*(w.close/w.total)
+		}
+		return saturate(src)
+	}
+	src := (high + low + close) / 3.0
+	if math.IsInf(src, 0) {
+		src = high + (low-high)/3 + (close-high)/3
+	}
+	return src
+}
+
+// saturate clamps an overflowed result to the largest finite float64 of the
+// same sign, leaving other values, NaN included, as they are
+func saturate(x float64) float64 {
+	if math.IsInf(x, 0) {
+		return math.Copysign(math.MaxFloat64, x)
+	}
+	return x
+}
+
+// update runs the calculation for the bands' high and low and the source
+// price feeding the mid line, for a bar at t or the zero time when untimed.
+// The close is only used by WithColorSource.
+func (im *ImpulseMACD) update(t time.Time, high, low, close, src float64) ImpulseValue {
+	im.checkReentry()
+	if t.IsZero() && im.clock != nil {
+		t = im.clock.Now()
+	}
+	if !t.IsZero() {
+		im.advanceTime(t)
+	}
 
 	// Update SMMA for high and low
-	hi := im.smmaHigh.Update(high)
-	lo := im.smmaLow.Update(low)
+	hi := im.maHigh.Update(high)
+	lo := im.maLow.Update(low)
 
-	// Update ZLEMA for HLC3
-	mi := im.zlema.Update(hlc3)
+	// Update ZLEMA for the source price
+	mi := im.updateMid(src)
 
-	// Calculate main difference (md)
+	// Calculate main difference (md), against a channel widened by the
+	// neutral band when set
+	upper, lower := hi, lo
+	if im.neutralBand > 0 {
+		margin := im.neutralBand * (hi - lo)
+		upper, lower = hi+margin, lo-margin
+	}
 	var md float64
-	if mi > hi {
-		md = mi - hi
-	} else if mi < lo {
-		md = mi - lo
+	if mi > upper {
+		md = mi - upper
+	} else if mi < lower {
+		md = mi - lower
 	} else {
 		md = 0
 	}
+	if im.percentMD {
+		md = percentOf(md, mi)
+	}
+	if im.mdTransform != nil {
+		md = im.mdTransform(md)
+	}
 
 	// Calculate signal (sb)
-	sb := im.signalSMA.Update(md)
+	sb := im.maSignal.Update(md)
 
 	// Calculate histogram (sh)
-	sh := md - sb
+	var sh float64
+	if !im.noHistogram {
+		sh = md - sb
+	}
 
-	// Determine color
-	var color string
-	if hlc3 > mi {
-		if hlc3 > hi {
-			color = "lime"
+	// Determine color. On the first bar every line is seeded from this bar,
+	// so the source sits on the mid line and the color is always orange
+	// rather than depending on rounding in the seeds.
+	price := src
+	if im.colorSource == ColorFromClose {
+		price = close
+	}
+	var color Color
+	if im.count == 0 {
+		color = ColorOrange
+	} else if price > mi {
+		if price > hi+im.colorEps {
+			color = ColorLime
 		} else {
-			color = "green"
+			color = ColorGreen
 		}
 	} else {
-		if hlc3 < lo {
-			color = "red"
+		if price < lo-im.colorEps {
+			color = ColorRed
 		} else {
-			color = "orange"
+			color = ColorOrange
 		}
 	}
 
 	value := ImpulseValue{
-		MD:    md,
-		SB:    sb,
-		SH:    sh,
-		Color: color,
+		MD:        md,
+		SB:        sb,
+		SH:        sh,
+		Color:     color,
+		Timestamp: t,
+		Valid:     im.count+1 >= im.MinBars() && im.signalFull(),
+	}
+	if im.gradedColor {
+		value.Intensity = colorIntensity(price, hi, lo)
+	}
+	if im.explain {
+		value.Reason = im.colorReason(color)
+	}
+	if im.shSmoothing != nil && !im.noHistogram {
+		value.SHSmoothed = im.shSmoothing.Update(sh)
+	}
+	if im.shStats != nil && !im.noHistogram {
+		mean, variance := im.shStats.Update(sh)
+		if std := math.Sqrt(variance); value.Valid && im.shStats.Full() && std > 0 {
+			value.SHZScore = (sh - mean) / std
+		}
+	}
+	if im.minMax != nil {
+		value.NormalizedMD = im.minMax.update(md)
+	}
+	if b := im.boost; b != nil {
+		value.BoostedMD = md
+		if colorIntensity(close, hi, lo) > b.multiple {
+			value.BoostedMD *= b.factor
+		}
+	}
+	if im.precision > 0 {
+		value.MD = math.Round(value.MD*im.precision) / im.precision
+		value.SB = math.Round(value.SB*im.precision) / im.precision
+		value.SH = math.Round(value.SH*im.precision) / im.precision
+		value.SHSmoothed = math.Round(value.SHSmoothed*im.precision) / im.precision
+		value.NormalizedMD = math.Round(value.NormalizedMD*im.precision) / im.precision
+		value.BoostedMD = math.Round(value.BoostedMD*im.precision) / im.precision
+		value.SHZScore = math.Round(value.SHZScore*im.precision) / im.precision
 	}
+	var prevSH float64
+	if n := len(im.values); im.count > 0 && n > 0 {
+		prevSH = im.values[n-1].SH
+	}
+	value.HistColor = histColor(prevSH, value.SH)
+
+	if im.previewing {
+		return value
+	}
+	im.forming, im.hasForming = ImpulseValue{}, false
 
-	im.values = append(im.values, value)
+	var prev ImpulseValue
+	hasPrev := im.count > 0 && len(im.values) > 0
+	if hasPrev {
+		prev = im.values[len(im.values)-1]
+	}
+
+	im.values = appendBounded(im.values, value, im.maxHistory)
+	if im.retainSources {
+		im.sources = appendBounded(im.sources, src, im.maxHistory)
+	}
+	if im.retainBands {
+		im.bands = appendBounded(im.bands, Bands{hi, lo, mi}, im.maxHistory)
+	}
+	if im.retainInputs {
+		im.inputs = appendBounded(im.inputs, barInput{t, high, low, close, src}, im.maxHistory)
+	}
+	im.count++
+	im.notify(hasPrev, prev, value)
 	return value
 }
 
+// appendBounded appends v keeping at most limit elements, or all of them when
+// limit is 0. When the retained window runs out of capacity it is moved into
+// a new buffer with room for further appends, so trimming stays amortized
+// O(1).
+func appendBounded[T any](s []T, v T, limit int) []T {
+	if limit > 0 && len(s) >= limit {
+		keep := s[len(s)-limit+1:]
+		if len(s) < cap(s) {
+			s = keep
+		} else {
+			s = make([]T, len(keep), limit+max(limit, 64))
+			copy(s, keep)
+		}
+	}
+	return append(s, v)
+}
+
+// updateMid feeds the source price to the mid line, in log space when
+// WithLogPrice is set. Exp can round past the float64 limit on the way back,
+// so the result saturates there.
+func (im *ImpulseMACD) updateMid(src float64) float64 {
+	if im.logPrice {
+		return saturate(math.Exp(im.maMid.Update(math.Log(src))))
+	}
+	return im.maMid.Update(src)
+}
+
+// midValue returns the current mid line in price terms
+func (im *ImpulseMACD) midValue() float64 {
+	if im.logPrice {
+		return saturate(math.Exp(im.maMid.Value()))
+	}
+	return im.maMid.Value()
+}
+
+// CurrentBands returns the latest high band, low band and mid line without
+// recomputing them. ok is false until the indicator is warmed up.
+func (im *ImpulseMACD) CurrentBands() (hi, lo, mid float64, ok bool) {
+	if im.count == 0 || !im.IsWarmedUp() {
+		return 0, 0, 0, false
+	}
+	return im.maHigh.Value(), im.maLow.Value(), im.midValue(), true
+}
+
+// GetBandSeries returns the high band, low band and mid line of every stored
+// value, aligned with GetValues. The series are copies, built on each call;
+// they are nil unless WithRetainBands is set.
+func (im *ImpulseMACD) GetBandSeries() (hi, lo, mid []float64) {
+	if !im.retainBands {
+		return nil, nil, nil
+	}
+	hi = make([]float64, len(im.bands))
+	lo = make([]float64, len(im.bands))
+	mid = make([]float64, len(im.bands))
+	for i, b := range im.bands {
+		hi[i], lo[i], mid[i] = b.High, b.Low, b.Mid
+	}
+	return hi, lo, mid
+}
+
+// BandWidth returns the distance between the high and low bands of the
+// latest bar, a cheap volatility proxy. ok is false until the indicator is
+// warmed up.
+func (im *ImpulseMACD) BandWidth() (width float64, ok bool) {
+	hi, lo, _, ok := im.CurrentBands()
+	if !ok {
+		return 0, false
+	}
+	return hi - lo, true
+}
+
+// NormalizedBandWidth returns BandWidth divided by the mid line, so widths
+// compare across price levels. ok is also false when the mid line is 0.
+func (im *ImpulseMACD) NormalizedBandWidth() (width float64, ok bool) {
+	hi, lo, mid, ok := im.CurrentBands()
+	if !ok || mid == 0 {
+		return 0, false
+	}
+	return (hi - lo) / mid, true
+}
+
 // GetValues returns all calculated values
 func (im *ImpulseMACD) GetValues() []ImpulseValue {
 	return im.values
 }
 
-// GetLatest returns the most recent calculation
+// TailView iterates over the last k stored values, oldest first, without
+// copying them. The values are those held when iteration starts, so a history
+// limit has already trimmed older ones; k above the stored count yields all
+// of them and k below 1 none.
+func (im *ImpulseMACD) TailView(k int) iter.Seq[ImpulseValue] {
+	return func(yield func(ImpulseValue) bool) {
+		values := im.values[len(im.values)-min(max(k, 0), len(im.values)):]
+		for _, v := range values {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// GetLatest returns the most recent calculation, which is the forming value
+// with WithLatestIncludesForming
 func (im *ImpulseMACD) GetLatest() *ImpulseValue {
+	if im.latestForming && im.hasForming {
+		return &im.forming
+	}
 	if len(im.values) == 0 {
 		return nil
 	}
 	return &im.values[len(im.values)-1]
 }
 
-// SMMA implementation
+// GetValueAt returns the value at index i of the calculated values, counting
+// back from the latest for negative i (-1 is the latest). ok is false when i
+// is out of range.
+func (im *ImpulseMACD) GetValueAt(i int) (ImpulseValue, bool) {
+	if i < 0 {
+		i += len(im.values)
+	}
+	if i < 0 || i >= len(im.values) {
+		return ImpulseValue{}, false
+	}
+	return im.values[i], true
+}
+
+// GetValuesSince returns a copy of the values stamped at or after t, found by
+// binary search as timestamps are assumed not to go backwards. Untimed values
+// carry the zero time, so they are only returned when t is the zero time,
+// which returns all values.
+func (im *ImpulseMACD) GetValuesSince(t time.Time) []ImpulseValue {
+	i, _ := slices.BinarySearchFunc(im.values, t, func(v ImpulseValue, t time.Time) int {
+		return v.Timestamp.Compare(t)
+	})
+	return slices.Clone(im.values[i:])
+}
+
+// TrimHistory drops all but the last keep values, along with the sources,
+// bands and inputs retained for them, and copies the rest into right-sized
+// slices so the memory of a burst is released. It is a no-op when at most
+// keep values are stored. keep below 1 is treated as 1 so GetLatest keeps the
+// latest value. Slices obtained from GetValues earlier are not affected.
+func (im *ImpulseMACD) TrimHistory(keep int) {
+	im.checkReentry()
+	keep = max(keep, 1)
+	if len(im.values) <= keep {
+		return
+	}
+	im.values = slices.Clone(im.values[len(im.values)-keep:])
+	if im.retainSources {
+		im.sources = slices.Clone(im.sources[len(im.sources)-keep:])
+	}
+	if im.retainBands {
+		im.bands = slices.Clone(im.bands[len(im.bands)-keep:])
+	}
+	if im.retainInputs {
+		im.inputs = slices.Clone(im.inputs[len(im.inputs)-keep:])
+	}
+}
+
+// SMMA implementation, lengths below 1 are treated as 1
 func NewSMMA(length int) *SMMA {
+	length = max(length, 1)
 	return &SMMA{
 		length: length,
 		isInit: false,
@@ -146,17 +725,47 @@ func NewSMMA(length int) *SMMA {
 }
 
 func (s *SMMA) Update(value float64) float64 {
+	s.count++
 	if !s.isInit {
 		s.value = value // First value acts as SMA base
 		s.isInit = true
+	} else if alpha, ok := s.decay.alpha(); ok {
+		s.value = (value * alpha) + (s.value * (1.0 - alpha))
 	} else {
-		s.value = (s.value*float64(s.length-1) + value) / float64(s.length)
+		next := (s.value*float64(s.length-1) + value) / float64(s.length)
+		if math.IsInf(next, 0) {
+			// value*(length-1) overflowed, so weight the terms first
+			next = s.value*(float64(s.length-1)/float64(s.length)) + value/float64(s.length)
+		}
+		s.value = next
 	}
 	return s.value
 }
 
-// ZLEMA implementation
+func (s *SMMA) Value() float64 {
+	return s.value
+}
+
+// Count returns the number of values seen since construction or Reset
+func (s *SMMA) Count() int {
+	return s.count
+}
+
+// IsReady reports whether at least length values have been seen
+func (s *SMMA) IsReady() bool {
+	return s.count >= s.length
+}
+
+func (s *SMMA) Reset() {
+	s.value = 0
+	s.isInit = false
+	s.count = 0
+	s.saved = nil
+}
+
+// ZLEMA implementation, lengths below 1 are treated as 1
 func NewZLEMA(length int) *ZLEMA {
+	length = max(length, 1)
 	return &ZLEMA{
 		length: length,
 		ema1:   NewEMA(length),
@@ -168,11 +777,38 @@ func (z *ZLEMA) Update(value float64) float64 {
 	ema1 := z.ema1.Update(value)
 	ema2 := z.ema2.Update(ema1)
 	d := ema1 - ema2
-	return ema1 + d
+	z.value = ema1 + d
+	if math.IsInf(z.value, 0) && !math.IsInf(ema1, 0) {
+		// Extrapolating a steep trend near the float64 limit passes it
+		z.value = saturate(z.value)
+	}
+	return z.value
+}
+
+func (z *ZLEMA) Value() float64 {
+	return z.value
+}
+
+// Count returns the number of values seen since construction or Reset
+func (z *ZLEMA) Count() int {
+	return z.ema1.count
+}
+
+// IsReady reports whether at least length values have been seen
+func (z *ZLEMA) IsReady() bool {
+	return z.ema1.IsReady()
+}
+
+func (z *ZLEMA) Reset() {
+	z.ema1.Reset()
+	z.ema2.Reset()
+	z.value = 0
+	z.saved = nil
 }
 
-// EMA implementation
+// EMA implementation, lengths below 1 are treated as 1
 func NewEMA(length int) *EMA {
+	length = max(length, 1)
 	multiplier := 2.0 / (float64(length) + 1.0)
 	return &EMA{
 		length:     length,
@@ -182,64 +818,225 @@ func NewEMA(length int) *EMA {
 }
 
 func (e *EMA) Update(value float64) float64 {
+	e.count++
 	if !e.isInit {
 		e.value = value
 		e.isInit = true
+	} else if alpha, ok := e.decay.alpha(); ok {
+		e.value = (value * alpha) + (e.value * (1.0 - alpha))
 	} else {
 		e.value = (value * e.multiplier) + (e.value * (1.0 - e.multiplier))
 	}
 	return e.value
 }
 
-// SMA implementation
+func (e *EMA) Value() float64 {
+	return e.value
+}
+
+// Count returns the number of values seen since construction or Reset
+func (e *EMA) Count() int {
+	return e.count
+}
+
+// IsReady reports whether at least length values have been seen
+func (e *EMA) IsReady() bool {
+	return e.count >= e.length
+}
+
+func (e *EMA) Reset() {
+	e.value = 0
+	e.isInit = false
+	e.count = 0
+	e.saved = nil
+}
+
+// SMA implementation, lengths below 1 are treated as 1
 func NewSMA(length int) *SMA {
+	length = max(length, 1)
 	return &SMA{
 		length: length,
-		values: make([]float64, 0, length),
+		window: NewSlidingWindow[float64](length),
 		sum:    0,
 	}
 }
 
 func (s *SMA) Update(value float64) float64 {
-	if len(s.values) < s.length {
-		s.values = append(s.values, value)
-		s.sum += value
-	} else {
-		s.sum -= s.values[0]
-		copy(s.values, s.values[1:])
-		s.values[s.length-1] = value
-		s.sum += value
+	s.count++
+	if oldest, ok := s.window.Push(value); ok {
+		s.sum -= oldest
+	}
+	s.sum += value
+
+	n := float64(s.window.Len())
+	if math.IsInf(s.sum, 0) || math.IsNaN(s.sum) {
+		// The running sum overflowed, and would stay infinite even once the
+		// large values leave the window, so rebuild it and fall back to a
+		// mean of scaled values while it does not fit
+		s.sum, s.value = 0, 0
+		for _, v := range s.window.All() {
+			s.sum += v
+			s.value += v / n
+		}
+		if !math.IsInf(s.sum, 0) && !math.IsNaN(s.sum) {
+			s.value = s.sum / n
+		}
+		return s.value
 	}
+	s.value = s.sum / n
+	return s.value
+}
+
+func (s *SMA) Value() float64 {
+	return s.value
+}
+
+// Count returns the number of values seen since construction or Reset
+func (s *SMA) Count() int {
+	return s.count
+}
 
-	return s.sum / float64(len(s.values))
+// IsReady reports whether the window holds length values
+func (s *SMA) IsReady() bool {
+	return s.window.Full()
 }
 
+func (s *SMA) Reset() {
+	s.window.Reset()
+	s.sum = 0
+	s.value = 0
+	s.count = 0
+	s.saved = nil
+}
+
+// Default lengths of the TradingView indicator
+const (
+	DefaultLengthMA     = 34
+	DefaultLengthSignal = 9
+)
+
 // Helper function to create default Impulse MACD (34, 9)
 func NewDefaultImpulseMACD() *ImpulseMACD {
-	return NewImpulseMACD(34, 9)
+	return NewImpulseMACD(DefaultLengthMA, DefaultLengthSignal)
 }
 
-// BatchUpdate processes multiple price bars at once
+// BatchUpdate processes multiple price bars at once. Empty input returns an
+// empty, non-nil result and leaves the state untouched.
 func (im *ImpulseMACD) BatchUpdate(bars []PriceBar) []ImpulseValue {
 	results := make([]ImpulseValue, len(bars))
 	for i, bar := range bars {
-		results[i] = im.Update(bar.High, bar.Low, bar.Close)
+		results[i] = im.updateBar(bar)
+	}
+	return results
+}
+
+// BatchUpdateHLC processes price data given as columns of highs, lows and
+// closes. The columns must have the same length; on a mismatch an error is
+// returned before any state changes. Empty columns behave like an empty
+// BatchUpdate.
+func (im *ImpulseMACD) BatchUpdateHLC(highs, lows, closes []float64) ([]ImpulseValue, error) {
+	if len(highs) != len(lows) || len(highs) != len(closes) {
+		return nil, fmt.Errorf("imacd: column lengths differ: %d highs, %d lows, %d closes",
+			len(highs), len(lows), len(closes))
+	}
+
+	results := make([]ImpulseValue, len(highs))
+	for i := range highs {
+		results[i] = im.Update(highs[i], lows[i], closes[i])
+	}
+	return results, nil
+}
+
+// BatchUpdateFunc processes price bars one at a time, passing each index and
+// result to fn instead of collecting them. Processing stops as soon as fn
+// returns false; the bar passed to that call has already been applied, so the
+// indicator state then covers bars[:i+1].
+func (im *ImpulseMACD) BatchUpdateFunc(bars []PriceBar, fn func(int, ImpulseValue) bool) {
+	for i, bar := range bars {
+		if !fn(i, im.updateBar(bar)) {
+			return
+		}
+	}
+}
+
+// BatchUpdateReversed processes price bars given newest first, iterating from
+// the end of the slice so the bars are applied oldest first. The results are
+// returned in chronological order.
+func (im *ImpulseMACD) BatchUpdateReversed(bars []PriceBar) ([]ImpulseValue, error) {
+	if len(bars) == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	results := make([]ImpulseValue, len(bars))
+	for i := range bars {
+		bar := bars[len(bars)-1-i]
+		results[i] = im.updateBar(bar)
+	}
+	return results, nil
+}
+
+// BatchUpdateFrom processes all price bars like BatchUpdate. When skipWarmup
+// is true the results of bars processed before the indicator is warmed up
+// are dropped, so only valid rows are returned.
+func (im *ImpulseMACD) BatchUpdateFrom(bars []PriceBar, skipWarmup bool) []ImpulseValue {
+	if !skipWarmup {
+		return im.BatchUpdate(bars)
+	}
+
+	results := make([]ImpulseValue, 0, len(bars))
+	for _, bar := range bars {
+		value := im.updateBar(bar)
+		if im.IsWarmedUp() {
+			results = append(results, value)
+		}
 	}
 	return results
 }
 
 // PriceBar re																																																																																																																										ents a price bar with OHLC data
 type PriceBar struct {
+	Open  float64 // Only used with WithPriceWeights
 	High  float64
 	Low   float64
 	Close float64
+	Time  time.Time // Optional, zero when the bar is untimed
+
+	// Quote prices and a precomputed source, only used with WithInputSelector
+	Bid, Ask float64
+	Source   float64
 }
 
 // Reset clears all internal state
 func (im *ImpulseMACD) Reset() {
-	im.smmaHigh = NewSMMA(im.lengthMA)
-	im.smmaLow = NewSMMA(im.lengthMA)
-	im.zlema = NewZLEMA(im.lengthMA)
-	im.signalSMA = NewSMA(im.lengthSignal)
-	im.values = make([]ImpulseValue, 0)
-}
\ No newline at end of file
+	im.ResetState(false)
+}
+
+// ResetState clears the sub-indicator state so the calculation restarts from
+// the next bar. The calculated values are kept when keepHistory is true.
+func (im *ImpulseMACD) ResetState(keepHistory bool) {
+	im.checkReentry()
+	im.maHigh.Reset()
+	im.maLow.Reset()
+	im.maMid.Reset()
+	im.maSignal.Reset()
+	if im.shSmoothing != nil {
+		im.shSmoothing.Reset()
+	}
+	if im.shStats != nil {
+		im.shStats.Reset()
+	}
+	if im.minMax != nil {
+		im.minMax.reset()
+	}
+	if !keepHistory {
+		im.values = make([]ImpulseValue, 0)
+		im.sources = nil
+		im.bands = nil
+		im.inputs = nil
+	}
+	im.count = 0
+	im.lastTime = time.Time{}
+	im.forming, im.hasForming = ImpulseValue{}, false
+	im.crosses.reset()
+	im.checkpoints = nil
+}
@@ -0,0 +1,62 @@
+package imacd
+
+import (
+	"math"
+	"testing"
+)
+
+// FuzzUpdate feeds a random walk of finite bars through indicators with
+// fuzzed lengths and options, checking that no update panics and that every
+// output stays finite. The walk starts at start and moves by up to half the
+// price per bar, three bytes of steps per bar setting the close move and how
+// far the high and low reach beyond it. It stops when the walk underflows or
+// overflows.
+func FuzzUpdate(f *testing.F) {
+	f.Add(uint8(34), uint8(9), uint8(0), 100.0, []byte{10, 20, 30, 250, 5, 5, 128, 60, 1, 3, 3, 3})
+	f.Add(uint8(1), uint8(1), uint8(0), 1.0, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0})
+	f.Add(uint8(5), uint8(3), uint8(0xff), 0.0001, []byte{127, 255, 255, 129, 255, 255, 127, 0, 0, 129, 0, 0})
+	f.Add(uint8(20), uint8(50), uint8(0x2a), 1e9, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15})
+	f.Add(uint8(2), uint8(2), uint8(0x15), 42000.5, []byte{200, 90, 90, 56, 90, 90, 200, 90, 90, 56, 90, 90})
+
+	f.Fuzz(func(t *testing.T, lengthMA, lengthSignal, flags uint8, start float64, steps []byte) {
+		if !(start > 0 && start <= 1e12) {
+			t.Skip()
+		}
+
+		var opts []Option
+		for bit, opt := range []Option{
+			WithTradingViewRMA(),
+			WithHistogramSmoothing(3),
+			WithSHZScore(5),
+			WithGradedColor(),
+			WithPercentMD(),
+			WithLogPrice(),
+			WithPersistentMinMax(10),
+			WithExtremeBoost(2, 1),
+		} {
+			if flags&(1<<bit) != 0 {
+				opts = append(opts, opt)
+			}
+		}
+		im, err := NewImpulseMACDWithOptions(int(lengthMA)+1, int(lengthSignal)+1, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		price := start
+		for i := 0; i+3 <= len(steps); i += 3 {
+			price *= 1 + float64(int8(steps[i]))/256
+			high := price * (1 + float64(steps[i+1])/512)
+			low := price * (1 - float64(steps[i+2])/512)
+			if !(low > 0) || math.IsInf(high, 0) {
+				break // The walk left the positive finite range
+			}
+			v := im.Update(high, low, price)
+			for _, out := range [...]float64{v.MD, v.SB, v.SH, v.SHSmoothed, v.Intensity, v.NormalizedMD, v.BoostedMD, v.SHZScore} {
+				if math.IsNaN(out) || math.IsInf(out, 0) {
+					t.Fatalf("bar %d (%v, %v, %v): non-finite output in %+v", i/3, high, low, price, v)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package imacd
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestFirstBarOrange checks that the first bar, which seeds every line, is
+// orange even when the color source sits above the seeded mid line
+func TestFirstBarOrange(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []Option
+		high, low, close float64
+	}{
+		{"default", nil, 10, 8, 10},
+		{"color_close", []Option{WithColorSource(ColorFromClose)}, 10, 8, 10},
+		{"color_close_low", []Option{WithColorSource(ColorFromClose)}, 10, 8, 8},
+		{"log_price", []Option{WithLogPrice()}, 10, 8, 10},
+		{"tv_rma", []Option{WithTradingViewRMA(), WithColorSource(ColorFromClose)}, 10, 8, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := newIndicator(t, tt.opts...)
+			for range 2 {
+				if v := im.Update(tt.high, tt.low, tt.close); v.Color != ColorOrange || v.Valid {
+					t.Fatalf("first bar = %+v, want orange and not valid", v)
+				}
+				im.Reset()
+			}
+		})
+	}
+
+	// The rule only covers the first bar
+	im := newIndicator(t, WithColorSource(ColorFromClose))
+	im.Update(10, 8, 10)
+	if v := im.Update(10, 8, 10); v.Color != ColorGreen {
+		t.Fatalf("second bar color = %v, want %v", v.Color, ColorGreen)
+	}
+}
+
+// TestColorEpsilonBoundaries places the close just on and just past hi+eps
+// and lo-eps, with flat bars holding the bands at 11 and 9
+func TestColorEpsilonBoundaries(t *testing.T) {
+	const eps = 0.5
+	tests := []struct {
+		close float64
+		want  Color
+	}{
+		{11 + eps, ColorGreen},
+		{math.Nextafter(11+eps, math.Inf(1)), ColorLime},
+		{math.Nextafter(11+eps, 0), ColorGreen},
+		{9 - eps, ColorOrange},
+		{math.Nextafter(9-eps, 0), ColorRed},
+		{math.Nextafter(9-eps, math.Inf(1)), ColorOrange},
+	}
+	for _, tt := range tests {
+		im := newIndicator(t, WithColorEpsilon(eps), WithColorSource(ColorFromClose))
+		for range 10 {
+			im.Update(11, 9, 10)
+		}
+		if v := im.Update(11, 9, tt.close); v.Color != tt.want {
+			t.Errorf("close %v: color %v, want %v", tt.close, v.Color, tt.want)
+		}
+	}
+}
+
+func TestColorEpsilonZeroMatchesDefault(t *testing.T) {
+	bars := randomBars(rand.New(rand.NewPCG(8, 184)), 300)
+	withEps := newIndicator(t, WithColorEpsilon(0))
+	plain := newIndicator(t)
+	for i, bar := range bars {
+		if got, want := withEps.Update(bar.High, bar.Low, bar.Close), plain.Update(bar.High, bar.Low, bar.Close); got != want {
+			t.Fatalf("bar %d: %+v with a zero epsilon, %+v without", i, got, want)
+		}
+	}
+	if !withEps.Equal(plain) {
+		t.Fatal("a zero epsilon changed the indicator")
+	}
+}
+
+func TestNewDefaultImpulseMACD(t *testing.T) {
+	def := NewDefaultImpulseMACD()
+	want := NewImpulseMACD(DefaultLengthMA, DefaultLengthSignal)
+	if !def.Equal(want) {
+		t.Fatal("NewDefaultImpulseMACD differs from NewImpulseMACD with the default lengths")
+	}
+	if def.lengthMA != 34 || def.lengthSignal != 9 {
+		t.Fatalf("default lengths are %d and %d, want 34 and 9", def.lengthMA, def.lengthSignal)
+	}
+	bars := randomBars(rand.New(rand.NewPCG(9, 126)), 80)
+	def.BatchUpdate(bars)
+	want.BatchUpdate(bars)
+	if !def.Equal(want) {
+		t.Fatal("default indicators diverge on the same bars")
+	}
+}
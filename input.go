@@ -0,0 +1,53 @@
+package imacd
+
+import (
+	"fmt"
+	"math"
+)
+
+// InputSelector selects which price of a PriceBar feeds the mid line. The
+// bands always use the bar's High and Low.
+type InputSelector int
+
+const (
+	// InputTrade derives the source from the trade prices, HLC3 or the
+	// WithPriceWeights combination
+	InputTrade InputSelector = iota
+	// InputQuoteMid uses the middle of the bar's Bid and Ask
+	InputQuoteMid
+	// InputSource uses the bar's precomputed Source as is, like UpdateTypical
+	InputSource
+)
+
+// WithInputSelector sets which price of a PriceBar feeds the mid line, so the
+// indicator can run on the quote mid or on a source computed upstream while
+// the bands stay on the traded high and low. It applies to the methods taking
+// a PriceBar; Update, UpdateOHLC and UpdateAt carry no quotes and always use
+// the trade prices. Bars without the selected price, with zero Bid and Ask or
+// a zero Source, fall back to the trade prices.
+func WithInputSelector(input InputSelector) Option {
+	return func(im *ImpulseMACD) error {
+		switch input {
+		case InputTrade, InputQuoteMid, InputSource:
+		default:
+			return fmt.Errorf("imacd: unknown input selector %d", input)
+		}
+		im.input = input
+		return nil
+	}
+}
+
+// barSource returns the source price of a bar for the input selector
+func (im *ImpulseMACD) barSource(bar PriceBar) float64 {
+	switch {
+	case im.input == InputQuoteMid && (bar.Bid != 0 || bar.Ask != 0):
+		src := (bar.Bid + bar.Ask) / 2
+		if math.IsInf(src, 0) {
+			src = bar.Bid/2 + bar.Ask/2
+		}
+		return src
+	case im.input == InputSource && bar.Source != 0:
+		return bar.Source
+	}
+	return im.source(bar.Open, bar.High, bar.Low, bar.Close)
+}
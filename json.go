@@ -0,0 +1,93 @@
+package imacd
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrConfigMismatch is returned by MustRestore when the snapshot was taken
+// from an indicator configured differently
+var ErrConfigMismatch = errors.New("imacd: snapshot configuration does not match")
+
+// jsonSnapshot is the JSON form of an indicator: its configuration and the
+// binary encoding of its state
+type jsonSnapshot struct {
+	Config Config `json:"config"`
+	State  []byte `json:"state"`
+}
+
+// MarshalJSON encodes the configuration and the state of the indicator. The
+// state holds what MarshalBinary encodes, so the same limitations apply.
+func (im *ImpulseMACD) MarshalJSON() ([]byte, error) {
+	state, err := im.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonSnapshot{im.Config(), state})
+}
+
+// UnmarshalJSON restores an indicator encoded by MarshalJSON. It fully
+// replaces the receiver, configuration included, so nothing from before the
+// restore is left behind; only the OnCross and OnValue handlers,
+// subscribers and the MD transform are kept.
+// The receiver is left untouched when an error is returned.
+func (im *ImpulseMACD) UnmarshalJSON(data []byte) error {
+	im.checkReentry()
+	restored, err := decodeSnapshot(data)
+	if err != nil {
+		return err
+	}
+	im.restoreFrom(restored)
+	return nil
+}
+
+// MustRestore is like UnmarshalJSON but refuses a snapshot whose
+// configuration differs from the receiver's, returning ErrConfigMismatch
+// instead of silently reconfiguring the indicator
+func (im *ImpulseMACD) MustRestore(data []byte) error {
+	im.checkReentry()
+	restored, err := decodeSnapshot(data)
+	if err != nil {
+		return err
+	}
+	if !restored.Config().equal(im.Config()) {
+		return ErrConfigMismatch
+	}
+	im.restoreFrom(restored)
+	return nil
+}
+
+// restoreFrom replaces the receiver with restored, keeping its handlers, MD
+// transform, clock and subscribers. The subscribers take the snapshot's policy, and
+// are created when the receiver is a zero ImpulseMACD.
+func (im *ImpulseMACD) restoreFrom(restored *ImpulseMACD) {
+	restored.crosses.handlers = im.crosses.handlers
+	restored.valueHandlers = im.valueHandlers
+	restored.mdTransform = im.mdTransform
+	restored.clock = im.clock
+	if b := im.subscribers; b != nil {
+		b.mu.Lock()
+		b.policy, b.buffer = restored.subscribers.policy, restored.subscribers.buffer
+		b.mu.Unlock()
+		restored.subscribers = b
+	}
+	*im = *restored
+}
+
+func decodeSnapshot(data []byte) (*ImpulseMACD, error) {
+	var snap jsonSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	restored, err := NewFromConfig(snap.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := restored.UnmarshalBinary(snap.State); err != nil {
+		return nil, err
+	}
+	if restored.lengthMA != snap.Config.LengthMA || restored.lengthSignal != snap.Config.LengthSignal {
+		return nil, errors.New("imacd: snapshot state does not match its configuration")
+	}
+	return restored, nil
+}
@@ -0,0 +1,76 @@
+package imacd
+
+import (
+	"fmt"
+	"slices"
+)
+
+// minMaxScaler tracks the range of MD, all-time or over a sliding window,
+// and maps values into [0, 1] against it
+type minMaxScaler struct {
+	window   int
+	min, max float64
+	seen     bool
+	// MD values in the window, oldest first, only kept when windowed
+	values []float64
+}
+
+// WithPersistentMinMax reports MD mapped into [0, 1] against its observed
+// range in ImpulseValue.NormalizedMD. With window 0 the range is the
+// all-time minimum and maximum; otherwise it covers the last window values,
+// rescanned in O(window) when an extreme leaves the window. The range
+// includes the current value and is encoded by MarshalBinary, so
+// normalization stays stable across sessions. A zero range maps to 0.5.
+func WithPersistentMinMax(window int) Option {
+	return func(im *ImpulseMACD) error {
+		if window < 0 {
+			return fmt.Errorf("imacd: min-max window must not be negative, got %d", window)
+		}
+		im.minMax = &minMaxScaler{window: window}
+		return nil
+	}
+}
+
+// update adds md to the range and returns it normalized
+func (s *minMaxScaler) update(md float64) float64 {
+	if s.window > 0 {
+		var dropped float64
+		full := len(s.values) == s.window
+		if full {
+			dropped = s.values[0]
+		}
+		s.values = appendBounded(s.values, md, s.window)
+		if full && (dropped == s.min || dropped == s.max) {
+			s.min, s.max = slices.Min(s.values), slices.Max(s.values)
+		}
+	}
+	if !s.seen {
+		s.min, s.max, s.seen = md, md, true
+	}
+	s.min, s.max = min(s.min, md), max(s.max, md)
+
+	if s.max == s.min {
+		return 0.5
+	}
+	return (md - s.min) / (s.max - s.min)
+}
+
+func (s *minMaxScaler) reset() {
+	s.min, s.max, s.seen = 0, 0, false
+	s.values = nil
+}
+
+// clone returns a copy of the scaler that shares no memory with it
+func (s *minMaxScaler) clone() *minMaxScaler {
+	c := *s
+	c.values = slices.Clone(s.values)
+	return &c
+}
+
+func (s *minMaxScaler) equal(other *minMaxScaler) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.window == other.window && s.min == other.min && s.max == other.max &&
+		s.seen == other.seen && slices.Equal(s.values, other.values)
+}
@@ -0,0 +1,145 @@
+package imacd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MultiEMA chains depth EMAs of the same length, each smoothing the output of
+// the previous one, and combines them with the generalized zero-lag
+// correction
+//
+//	sum over k = 1..depth of (-1)^(k+1) * C(depth, k) * EMA_k
+//
+// where EMA_k is the k-th EMA of the chain. This is 1 - (1 - EMA)^depth
+// applied as an operator, which cancels the lag of a single EMA up to order
+// depth-1:
+//
+//	depth 1: EMA_1, a plain EMA
+//	depth 2: 2*EMA_1 - EMA_2, DEMA, the same as ZLEMA here
+//	depth 3: 3*EMA_1 - 3*EMA_2 + EMA_3, TEMA
+//
+// Deeper chains track the input more closely and overshoot more on turns.
+type MultiEMA struct {
+	length  int
+	emas    []*EMA
+	weights []float64
+	value   float64
+	saved   []float64
+}
+
+// NewMultiEMA creates a chain of depth EMAs with the zero-lag correction,
+// lengths and depths below 1 are treated as 1
+func NewMultiEMA(length, depth int) *MultiEMA {
+	length, depth = max(length, 1), max(depth, 1)
+	m := &MultiEMA{
+		length:  length,
+		emas:    make([]*EMA, depth),
+		weights: make([]float64, depth),
+	}
+	binomial, sign := 1.0, 1.0
+	for k := range depth {
+		m.emas[k] = NewEMA(length)
+		binomial = binomial * float64(depth-k) / float64(k+1)
+		m.weights[k] = sign * binomial
+		sign = -sign
+	}
+	return m
+}
+
+func (m *MultiEMA) Update(value float64) float64 {
+	sum := 0.0
+	for k, e := range m.emas {
+		value = e.Update(value)
+		sum += m.weights[k] * value
+	}
+	if first := m.emas[0].value; (math.IsInf(sum, 0) || math.IsNaN(sum)) && !math.IsInf(first, 0) && !math.IsNaN(first) {
+		// A weighted term overflowed near the float64 limit. The weights sum
+		// to 1, so add the weighted offsets from the first EMA instead.
+		sum = first
+		for k, e := range m.emas[1:] {
+			sum += m.weights[k+1] * (e.value - first)
+		}
+		sum = saturate(sum)
+	}
+	m.value = sum
+	return m.value
+}
+
+func (m *MultiEMA) Value() float64 {
+	return m.value
+}
+
+// Depth returns the number of chained EMAs
+func (m *MultiEMA) Depth() int {
+	return len(m.emas)
+}
+
+// Count returns the number of values seen since construction or Reset
+func (m *MultiEMA) Count() int {
+	return m.emas[0].count
+}
+
+// IsReady reports whether at least length values have been seen
+func (m *MultiEMA) IsReady() bool {
+	return m.emas[0].IsReady()
+}
+
+func (m *MultiEMA) Reset() {
+	for _, e := range m.emas {
+		e.Reset()
+	}
+	m.value = 0
+	m.saved = nil
+}
+
+func (m *MultiEMA) push() {
+	for _, e := range m.emas {
+		e.push()
+	}
+	m.saved = append(m.saved, m.value)
+}
+
+func (m *MultiEMA) pop() {
+	for _, e := range m.emas {
+		e.pop()
+	}
+	m.value = m.saved[len(m.saved)-1]
+	m.saved = m.saved[:len(m.saved)-1]
+}
+
+func (m *MultiEMA) drop() {
+	for _, e := range m.emas {
+		e.drop()
+	}
+	m.saved = m.saved[:len(m.saved)-1]
+}
+
+// WithMidDepth smooths the mid line with a MultiEMA of the given depth
+// instead of the ZLEMA. Depth 2 is the ZLEMA and keeps it, so the indicator
+// is unchanged. Other depths make the mid line a non built-in average, which
+// operations needing the default moving averages, such as binary
+// serialization and time decay, do not support.
+func WithMidDepth(depth int) Option {
+	return func(im *ImpulseMACD) error {
+		if depth < 1 {
+			return fmt.Errorf("imacd: mid line depth must be positive, got %d", depth)
+		}
+		switch im.maMid.(type) {
+		case *ZLEMA, *MultiEMA:
+		default:
+			return ErrCustomMovingAverage
+		}
+		if depth == 2 {
+			im.maMid = NewZLEMA(im.lengthMA)
+			im.applyTimeDecay()
+			return nil
+		}
+		if im.timeDecay > 0 {
+			return errors.New("imacd: time decay needs the ZLEMA mid line")
+		}
+		im.maMid = NewMultiEMA(im.lengthMA, depth)
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package imacd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestMidDepthTwoKeepsTimeDecay checks that WithMidDepth(2), which rebuilds
+// the ZLEMA, keeps the halflife set by an earlier WithTimeDecay
+func TestMidDepthTwoKeepsTimeDecay(t *testing.T) {
+	withDepth, err := NewImpulseMACDWithOptions(5, 3, WithTimeDecay(time.Minute), WithMidDepth(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := NewImpulseMACDWithOptions(5, 3, WithTimeDecay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 30 {
+		bar := PriceBar{High: float64(i + 2), Low: float64(i), Close: float64(i + 1),
+			Time: start.Add(time.Duration(i*i) * time.Second)}
+		withDepth.BatchUpdate([]PriceBar{bar})
+		plain.BatchUpdate([]PriceBar{bar})
+	}
+	if !withDepth.Equal(plain) {
+		t.Fatal("WithMidDepth(2) changed a time decayed indicator")
+	}
+	rebuilt, err := NewFromConfig(withDepth.Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mid := rebuilt.maMid.(*ZLEMA); mid.ema1.decay.halflife != time.Minute {
+		t.Fatalf("mid line halflife from Config is %v, want %v", mid.ema1.decay.halflife, time.Minute)
+	}
+}
+
+// TestMultiEMAOverflowFallback checks the offset form used when a weighted
+// term overflows against the same inputs scaled down by a power of two,
+// which is exact, so both must agree
+func TestMultiEMAOverflowFallback(t *testing.T) {
+	const scale = 0x1p-100
+	big, small := NewMultiEMA(4, 3), NewMultiEMA(4, 3)
+	for i, x := range []float64{0.9, 0.8, 0.85, 0.7, 0.75, 0.6} {
+		x *= math.MaxFloat64
+		got := big.Update(x)
+		want := small.Update(x*scale) / scale
+		if math.IsInf(got, 0) || math.Abs(got-want) > 1e-12*math.Abs(want) {
+			t.Fatalf("value %d: got %v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package imacd
+
+import "math"
+
+// Number is the arithmetic needed to run the indicator on a numeric type
+// other than float64, such as a fixed-point decimal. The method set matches
+// github.com/shopspring/decimal so Decimal satisfies it directly.
+type Number[T any] interface {
+	Add(T) T
+	Sub(T) T
+	Mul(T) T
+	Div(T) T
+	Cmp(T) int
+}
+
+// Float is the float64 Number backend
+type Float float64
+
+func (f Float) Add(o Float) Float { return f + o }
+func (f Float) Sub(o Float) Float { return f - o }
+func (f Float) Mul(o Float) Float { return f * o }
+func (f Float) Div(o Float) Float { return f / o }
+
+// Round rounds to the given number of decimals, as WithOutputPrecision does
+func (f Float) Round(places int32) Float {
+	scale := math.Pow(10, float64(places))
+	return Float(math.Round(float64(f)*scale) / scale)
+}
+
+func (f Float) Cmp(o Float) int {
+	switch {
+	case f < o:
+		return -1
+	case f > o:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ImpulseValueOf is a single calculation result of ImpulseMACDOf
+type ImpulseValueOf[T Number[T]] struct {
+	MD    T     // Main difference
+	SB    T     // Signal
+	SH    T     // Histogram (MD - SB)
+	Color Color // Color indication
+}
+
+// ImpulseMACDOf is the Impulse MACD indicator over an arbitrary Number type.
+// It mirrors the float64 ImpulseMACD core calculation, including the orange
+// first bar, with only the color epsilon and output precision options.
+type ImpulseMACDOf[T Number[T]] struct {
+	lengthMA     int
+	lengthSignal int
+	fromInt      func(int64) T
+	colorEps     T
+	precision    int32 // Decimals to round to, -1 for none
+
+	smmaHigh *smmaOf[T]
+	smmaLow  *smmaOf[T]
+	zlema    *zlemaOf[T]
+	signal   *smaOf[T]
+
+	values []ImpulseValueOf[T]
+}
+
+// OptionOf configures an ImpulseMACDOf
+type OptionOf[T Number[T]] func(*ImpulseMACDOf[T])
+
+// WithColorEpsilonOf is WithColorEpsilon for ImpulseMACDOf: the source must
+// clear a band by more than eps for lime or red. Negative values mean 0.
+func WithColorEpsilonOf[T Number[T]](eps T) OptionOf[T] {
+	return func(im *ImpulseMACDOf[T]) {
+		if eps.Cmp(im.fromInt(0)) > 0 {
+			im.colorEps = eps
+		}
+	}
+}
+
+// WithOutputPrecisionOf is WithOutputPrecision for ImpulseMACDOf, rounding
+// MD, SB and SH half away from zero. It needs a Round(int32) T method, as
+// Float and shopspring/decimal have; other types are not rounded.
+func WithOutputPrecisionOf[T Number[T]](decimals int) OptionOf[T] {
+	return func(im *ImpulseMACDOf[T]) {
+		im.precision = -1
+		if decimals > 0 {
+			im.precision = int32(decimals)
+		}
+	}
+}
+
+// rounder is implemented by Number types supporting WithOutputPrecisionOf
+type rounder[T any] interface {
+	Round(places int32) T
+}
+
+// NewImpulseMACDOf creates an Impulse MACD indicator over T, using fromInt
+// to build the constants the calculation needs. Lengths below 1 are treated
+// as 1.
+func NewImpulseMACDOf[T Number[T]](lengthMA, lengthSignal int, fromInt func(int64) T, opts ...OptionOf[T]) *ImpulseMACDOf[T] {
+	lengthMA, lengthSignal = max(lengthMA, 1), max(lengthSignal, 1)
+	im := &ImpulseMACDOf[T]{
+		lengthMA:     lengthMA,
+		lengthSignal: lengthSignal,
+		fromInt:      fromInt,
+		colorEps:     fromInt(0),
+		precision:    -1,
+	}
+	for _, opt := range opts {
+		opt(im)
+	}
+	im.Reset()
+	return im
+}
+
+// NewImpulseMACDFloat creates an ImpulseMACDOf using the float64 backend
+func NewImpulseMACDFloat(lengthMA, lengthSignal int, opts ...OptionOf[Float]) *ImpulseMACDOf[Float] {
+	return NewImpulseMACDOf(lengthMA, lengthSignal, func(v int64) Float { return Float(v) }, opts...)
+}
+
+// Update processes new price data (high, low, close)
+func (im *ImpulseMACDOf[T]) Update(high, low, close T) ImpulseValueOf[T] {
+	hlc3 := high.Add(low).Add(close).Div(im.fromInt(3))
+
+	hi := im.smmaHigh.update(high)
+	lo := im.smmaLow.update(low)
+	mi := im.zlema.update(hlc3)
+
+	md := im.fromInt(0)
+	if mi.Cmp(hi) > 0 {
+		md = mi.Sub(hi)
+	} else if mi.Cmp(lo) < 0 {
+		md = mi.Sub(lo)
+	}
+
+	sb := im.signal.update(md)
+
+	// The first bar seeds every line from itself, so it is orange as in the
+	// float64 core
+	color := ColorOrange
+	if len(im.values) > 0 {
+		color = classifyColor(hlc3.Cmp(mi), hlc3.Cmp(hi.Add(im.colorEps)), hlc3.Cmp(lo.Sub(im.colorEps)))
+	}
+	value := ImpulseValueOf[T]{
+		MD:    md,
+		SB:    sb,
+		SH:    md.Sub(sb),
+		Color: color,
+	}
+	if im.precision >= 0 {
+		if _, ok := any(md).(rounder[T]); ok {
+			value.MD = any(value.MD).(rounder[T]).Round(im.precision)
+			value.SB = any(value.SB).(rounder[T]).Round(im.precision)
+			value.SH = any(value.SH).(rounder[T]).Round(im.precision)
+		}
+	}
+	im.values = append(im.values, value)
+	return value
+}
+
+// GetValues returns all calculated values
+func (im *ImpulseMACDOf[T]) GetValues() []ImpulseValueOf[T] {
+	return im.values
+}
+
+// Reset clears all internal state
+func (im *ImpulseMACDOf[T]) Reset() {
+	im.smmaHigh = newSMMAOf(im.lengthMA, im.fromInt)
+	im.smmaLow = newSMMAOf(im.lengthMA, im.fromInt)
+	im.zlema = &zlemaOf[T]{
+		ema1: newEMAOf(im.lengthMA, im.fromInt),
+		ema2: newEMAOf(im.lengthMA, im.fromInt),
+	}
+	im.signal = &smaOf[T]{length: im.lengthSignal, fromInt: im.fromInt}
+	im.values = make([]ImpulseValueOf[T], 0)
+}
+
+// classifyColor maps the comparisons of the source against the mid line and
+// bands (as returned by Cmp) onto a bar color
+func classifyColor(vsMid, vsHigh, vsLow int) Color {
+	if vsMid > 0 {
+		if vsHigh > 0 {
+			return ColorLime
+		}
+		return ColorGreen
+	}
+	if vsLow < 0 {
+		return ColorRed
+	}
+	return ColorOrange
+}
+
+type smmaOf[T Number[T]] struct {
+	length   T
+	lengthM1 T
+	value    T
+	isInit   bool
+}
+
+func newSMMAOf[T Number[T]](length int, fromInt func(int64) T) *smmaOf[T] {
+	return &smmaOf[T]{
+		length:   fromInt(int64(length)),
+		lengthM1: fromInt(int64(length) - 1),
+	}
+}
+
+func (s *smmaOf[T]) update(value T) T {
+	if !s.isInit {
+		s.value = value
+		s.isInit = true
+	} else {
+		s.value = s.value.Mul(s.lengthM1).Add(value).Div(s.length)
+	}
+	return s.value
+}
+
+type emaOf[T Number[T]] struct {
+	multiplier T
+	one        T
+	value      T
+	isInit     bool
+}
+
+func newEMAOf[T Number[T]](length int, fromInt func(int64) T) *emaOf[T] {
+	return &emaOf[T]{
+		multiplier: fromInt(2).Div(fromInt(int64(length) + 1)),
+		one:        fromInt(1),
+	}
+}
+
+func (e *emaOf[T]) update(value T) T {
+	if !e.isInit {
+		e.value = value
+		e.isInit = true
+	} else {
+		e.value = value.Mul(e.multiplier).Add(e.value.Mul(e.one.Sub(e.multiplier)))
+	}
+	return e.value
+}
+
+type zlemaOf[T Number[T]] struct {
+	ema1 *emaOf[T]
+	ema2 *emaOf[T]
+}
+
+func (z *zlemaOf[T]) update(value T) T {
+	ema1 := z.ema1.update(value)
+	ema2 := z.ema2.update(ema1)
+	return ema1.Add(ema1.Sub(ema2))
+}
+
+type smaOf[T Number[T]] struct {
+	length  int
+	fromInt func(int64) T
+	values  []T
+	sum     T
+}
+
+func (s *smaOf[T]) update(value T) T {
+	if len(s.values) == 0 {
+		s.sum = s.fromInt(0)
+	}
+	if len(s.values) < s.length {
+		s.values = append(s.values, value)
+		s.sum = s.sum.Add(value)
+	} else {
+		s.sum = s.sum.Sub(s.values[0])
+		copy(s.values, s.values[1:])
+		s.values[s.length-1] = value
+		s.sum = s.sum.Add(value)
+	}
+	return s.sum.Div(s.fromInt(int64(len(s.values))))
+}
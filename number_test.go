@@ -0,0 +1,48 @@
+package imacd
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestFloatBackendMatchesCore checks that ImpulseMACDOf over Float gives the
+// float64 core's MD, SB, SH and color on every bar, the orange first bar
+// included, with and without the options both support
+func TestFloatBackendMatchesCore(t *testing.T) {
+	cases := map[string]struct {
+		opts   []Option
+		optsOf []OptionOf[Float]
+	}{
+		"default":   {},
+		"epsilon":   {[]Option{WithColorEpsilon(0.05)}, []OptionOf[Float]{WithColorEpsilonOf(Float(0.05))}},
+		"precision": {[]Option{WithOutputPrecision(3)}, []OptionOf[Float]{WithOutputPrecisionOf[Float](3)}},
+	}
+	bars := randomBars(rand.New(rand.NewPCG(7, 110)), 200)
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			core, err := NewImpulseMACDWithOptions(8, 5, c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			generic := NewImpulseMACDFloat(8, 5, c.optsOf...)
+			for i, bar := range bars {
+				want := core.Update(bar.High, bar.Low, bar.Close)
+				got := generic.Update(Float(bar.High), Float(bar.Low), Float(bar.Close))
+				if float64(got.MD) != want.MD || float64(got.SB) != want.SB || float64(got.SH) != want.SH || got.Color != want.Color {
+					t.Fatalf("bar %d: generic %+v, core %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFloatBackendFirstBarOrange(t *testing.T) {
+	im := NewImpulseMACDFloat(3, 2)
+	if v := im.Update(10, 9, 10); v.Color != ColorOrange {
+		t.Fatalf("first bar color = %v, want %v", v.Color, ColorOrange)
+	}
+	im.Reset()
+	if v := im.Update(10, 9, 10); v.Color != ColorOrange {
+		t.Fatalf("first bar after Reset color = %v, want %v", v.Color, ColorOrange)
+	}
+}
@@ -0,0 +1,44 @@
+package imacd
+
+import "time"
+
+// OHLCV is a candle as commonly used by market data libraries
+type OHLCV struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// FromOHLCV converts a candle to a PriceBar, dropping the volume
+func FromOHLCV(c OHLCV) PriceBar {
+	return PriceBar{
+		Open:  c.Open,
+		High:  c.High,
+		Low:   c.Low,
+		Close: c.Close,
+		Time:  c.Time,
+	}
+}
+
+// ToOHLCV converts a PriceBar to a candle with zero volume
+func ToOHLCV(bar PriceBar) OHLCV {
+	return OHLCV{
+		Time:  bar.Time,
+		Open:  bar.Open,
+		High:  bar.High,
+		Low:   bar.Low,
+		Close: bar.Close,
+	}
+}
+
+// BatchUpdateOHLCV processes multiple candles at once, like BatchUpdate
+func (im *ImpulseMACD) BatchUpdateOHLCV(candles []OHLCV) []ImpulseValue {
+	results := make([]ImpulseValue, len(candles))
+	for i, c := range candles {
+		results[i] = im.updateBar(FromOHLCV(c))
+	}
+	return results
+}
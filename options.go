@@ -0,0 +1,398 @@
+package imacd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrInvalidPrice is returned by UpdateChecked for NaN or infinite prices
+	ErrInvalidPrice = errors.New("imacd: price is NaN or infinite")
+	// ErrNonPositivePrice is returned by UpdateChecked for prices that are
+	// not positive when WithLogPrice is set
+	ErrNonPositivePrice = errors.New("imacd: price must be positive with log prices")
+)
+
+// Option configures an indicator created with NewImpulseMACDWithOptions
+type Option func(*ImpulseMACD) error
+
+// NewImpulseMACDWithOptions creates a new Impulse MACD indicator, validating
+// the lengths and applying the given options in order
+func NewImpulseMACDWithOptions(lengthMA, lengthSignal int, opts ...Option) (*ImpulseMACD, error) {
+	if lengthMA < 1 || lengthSignal < 1 {
+		return nil, fmt.Errorf("imacd: lengths must be positive, got (%d, %d)", lengthMA, lengthSignal)
+	}
+
+	im := NewImpulseMACD(lengthMA, lengthSignal)
+	for _, opt := range opts {
+		if err := opt(im); err != nil {
+			return nil, err
+		}
+	}
+	return im, nil
+}
+
+// WithLogPrice smooths the mid line on the log of the source price and
+// exponentiates the result, so it is compared against the bands in price
+// terms. Update does not check its inputs; use UpdateChecked to reject
+// non-positive prices instead of producing NaN.
+func WithLogPrice() Option {
+	return func(im *ImpulseMACD) error {
+		im.logPrice = true
+		return nil
+	}
+}
+
+// WithNoHistory retains only the most recent value instead of the full
+// history, keeping memory constant. GetLatest keeps working, but history
+// based queries only see the latest bar.
+func WithNoHistory() Option {
+	return WithMaxHistory(1)
+}
+
+// WithMaxHistory retains at most n of the most recent values, or all of them
+// when n is 0. History based queries only see the retained values.
+func WithMaxHistory(n int) Option {
+	return func(im *ImpulseMACD) error {
+		if n < 0 {
+			return fmt.Errorf("imacd: max history must not be negative, got %d", n)
+		}
+		im.maxHistory = n
+		return nil
+	}
+}
+
+// WithHistogramSmoothing reports an EMA of the histogram with the given
+// length in ImpulseValue.SHSmoothed. SH itself is left unsmoothed.
+func WithHistogramSmoothing(length int) Option {
+	return func(im *ImpulseMACD) error {
+		if length < 1 {
+			return fmt.Errorf("imacd: histogram smoothing length must be positive, got %d", length)
+		}
+		im.shSmoothing = NewEMA(length)
+		return nil
+	}
+}
+
+// WithSHZScore reports the histogram standardized over a rolling window in
+// ImpulseValue.SHZScore: (SH - mean) / std, with the mean and standard
+// deviation of the last window SH values from a RollingStats. The score is 0
+// until the indicator is warmed up and the window is full, and whenever the
+// standard deviation is 0. WithSampleStatistics selects the variance divisor.
+func WithSHZScore(window int) Option {
+	return func(im *ImpulseMACD) error {
+		if window < 2 {
+			return fmt.Errorf("imacd: z-score window must be at least 2, got %d", window)
+		}
+		im.shStats = NewRollingStats(window)
+		im.shStats.SetSample(im.sampleStats)
+		return nil
+	}
+}
+
+// WithOutputPrecision rounds MD, SB, SH, SHSmoothed, NormalizedMD, BoostedMD
+// and SHZScore in the emitted values to the given number of decimals. The
+// internal calculation keeps full precision. Zero or negative decimals
+// disable rounding.
+func WithOutputPrecision(decimals int) Option {
+	return func(im *ImpulseMACD) error {
+		im.precision = 0
+		if decimals > 0 {
+			im.precision = math.Pow10(decimals)
+		}
+		return nil
+	}
+}
+
+// WithCrossDebounce only reports a crossover when at least minBars bars have
+// passed since the last reported crossover in the same direction. Suppressed
+// crosses do not call OnCross handlers or change LastCross; LastCrossInfo
+// scans the raw values and still sees them.
+func WithCrossDebounce(minBars int) Option {
+	return func(im *ImpulseMACD) error {
+		if minBars < 0 {
+			return fmt.Errorf("imacd: cross debounce must not be negative, got %d", minBars)
+		}
+		im.crosses.debounce = minBars
+		return nil
+	}
+}
+
+// priceWeights are the weights of the source price and their sum
+type priceWeights struct {
+	open, high, low, close, total float64
+}
+
+// WithPriceWeights computes the source price as the weighted average of the
+// open, high, low and close, generalizing HLC3 (0, 1, 1, 1) and OHLC4
+// (1, 1, 1, 1). The weights are normalized by their sum, which must not be
+// zero.
+func WithPriceWeights(open, high, low, close float64) Option {
+	return func(im *ImpulseMACD) error {
+		total := open + high + low + close
+		if total == 0 || math.IsNaN(total) || math.IsInf(total, 0) {
+			return fmt.Errorf("imacd: price weights must have a finite non-zero sum, got %v", total)
+		}
+		im.weights = &priceWeights{open, high, low, close, total}
+		return nil
+	}
+}
+
+// WithRetainSources stores the source price of every bar alongside the
+// calculated values, subject to the same history limit. It is needed by
+// HistogramPriceCorrelation.
+func WithRetainSources() Option {
+	return func(im *ImpulseMACD) error {
+		im.retainSources = true
+		return nil
+	}
+}
+
+// WithRetainBands stores the high band, low band and mid line of every bar
+// alongside the calculated values, subject to the same history limit. It is
+// needed by MidSlope and GetBandSeries.
+func WithRetainBands() Option {
+	return func(im *ImpulseMACD) error {
+		im.retainBands = true
+		return nil
+	}
+}
+
+// WithNeutralBand widens the channel used for MD by frac times the band
+// spread (hi - lo) on each side, so MD stays zero while the mid line is within
+// that margin of a band. Beyond it MD is measured from the widened channel, so
+// it starts from zero instead of jumping. With frac 0.1 and bands 100 and 110
+// the mid line must pass 111 or 99 for a non-zero MD. Colors still use the
+// unwidened bands.
+func WithNeutralBand(frac float64) Option {
+	return func(im *ImpulseMACD) error {
+		if frac < 0 || math.IsNaN(frac) || math.IsInf(frac, 0) {
+			return fmt.Errorf("imacd: neutral band must be a non-negative fraction, got %v", frac)
+		}
+		im.neutralBand = frac
+		return nil
+	}
+}
+
+// WithBandLength smooths the high and low bands with an SMMA of length n
+// instead of lengthMA, while the mid line keeps lengthMA
+func WithBandLength(n int) Option {
+	return func(im *ImpulseMACD) error {
+		if n < 1 {
+			return fmt.Errorf("imacd: band length must be positive, got %d", n)
+		}
+		if im.customBands() {
+			return ErrCustomMovingAverage
+		}
+		im.bandLength = n
+		im.maHigh = im.newBand(n)
+		im.maLow = im.newBand(n)
+		im.applyTimeDecay()
+		return nil
+	}
+}
+
+// bandLen returns the length of the band moving averages
+func (im *ImpulseMACD) bandLen() int {
+	if im.bandLength > 0 {
+		return im.bandLength
+	}
+	return im.lengthMA
+}
+
+// WithGradedColor grades the color by how far the source price is beyond
+// the band, reported in ImpulseValue.Intensity: 0 inside the channel and
+// growing by 1 per band width (hi - lo) past the band
+func WithGradedColor() Option {
+	return func(im *ImpulseMACD) error {
+		im.gradedColor = true
+		return nil
+	}
+}
+
+// WithColorEpsilon treats a source price within eps of a band as on the band,
+// so it gets the milder color: green rather than lime at the high band and
+// orange rather than red at the low band. This keeps the color from
+// flickering while a flat market hugs a band. The comparison with the mid
+// line is unchanged, and the default of 0 keeps the strict comparisons.
+func WithColorEpsilon(eps float64) Option {
+	return func(im *ImpulseMACD) error {
+		if eps < 0 || math.IsNaN(eps) || math.IsInf(eps, 0) {
+			return fmt.Errorf("imacd: color epsilon must be finite and non-negative, got %v", eps)
+		}
+		im.colorEps = eps
+		return nil
+	}
+}
+
+// ColorSource selects the price the color compares with the mid line and
+// bands
+type ColorSource int
+
+const (
+	// ColorFromSource compares the source price feeding the mid line, HLC3
+	// by default, as the TradingView indicator does
+	ColorFromSource ColorSource = iota
+	// ColorFromClose compares the close, as some platforms do
+	ColorFromClose
+)
+
+// WithColorSource selects the price the color is classified from. MD, SB and
+// SH are unchanged; only the colors, and the Intensity of WithGradedColor,
+// differ. UpdateTypical has no close, so it classifies its typical price.
+func WithColorSource(src ColorSource) Option {
+	return func(im *ImpulseMACD) error {
+		if src != ColorFromSource && src != ColorFromClose {
+			return fmt.Errorf("imacd: unknown color source %d", src)
+		}
+		im.colorSource = src
+		return nil
+	}
+}
+
+// WithExplain populates ImpulseValue.Reason with the comparisons that decided
+// the color, such as "hlc3>mi && hlc3>hi -> lime", for teaching and debugging.
+// The price is named hlc3 for the default source, close with ColorFromClose
+// and src otherwise, and a WithColorEpsilon margin shows as +eps or -eps.
+// Building the string costs an allocation per bar, so it is off by default.
+func WithExplain(enabled bool) Option {
+	return func(im *ImpulseMACD) error {
+		im.explain = enabled
+		return nil
+	}
+}
+
+// colorReason describes the comparisons behind color for WithExplain
+func (im *ImpulseMACD) colorReason(color Color) string {
+	if im.count == 0 {
+		return "first bar -> " + string(color)
+	}
+
+	price := "src"
+	switch {
+	case im.colorSource == ColorFromClose:
+		price = "close"
+	case im.weights == nil && im.input == InputTrade:
+		price = "hlc3"
+	}
+	hi, lo := "hi", "lo"
+	if im.colorEps > 0 {
+		hi, lo = "hi+eps", "lo-eps"
+	}
+
+	switch color {
+	case ColorLime:
+		return price + ">mi && " + price + ">" + hi + " -> lime"
+	case ColorGreen:
+		return price + ">mi && " + price + "<=" + hi + " -> green"
+	case ColorRed:
+		return price + "<=mi && " + price + "<" + lo + " -> red"
+	default:
+		return price + "<=mi && " + price + ">=" + lo + " -> orange"
+	}
+}
+
+// WithPercentMD expresses MD as a signed percentage of the mid line,
+// (mi - band) / |mi| * 100, so thresholds are portable across instruments.
+// SB and SH are derived from it as usual. When the mid line is 0 the
+// percentage is undefined and MD is reported as 0.
+func WithPercentMD() Option {
+	return func(im *ImpulseMACD) error {
+		im.percentMD = true
+		return nil
+	}
+}
+
+// WithMDTransform applies fn to MD before it feeds the signal line, so SB, SH
+// and the MD reported are all on the transformed scale, for experiments such
+// as math.Tanh compression. It runs after WithPercentMD. The colors still
+// compare the source with the raw bands. fn must be pure: it is called once
+// per update, again on replays such as checkpoints, merges and SetLengthMA,
+// and concurrently by BatchUpdateMany. The cross plateau rules treat an MD of
+// exactly 0 as inside the channel, so fn should map 0 to 0. The transform is
+// not part of the Config and is not serialized.
+func WithMDTransform(fn func(float64) float64) Option {
+	return func(im *ImpulseMACD) error {
+		if fn == nil {
+			return errors.New("imacd: MD transform must not be nil")
+		}
+		im.mdTransform = fn
+		return nil
+	}
+}
+
+// percentOf returns diff as a percentage of |base|, or 0 when base is 0
+func percentOf(diff, base float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return diff / math.Abs(base) * 100
+}
+
+// WithoutHistogram skips the histogram, so SH and SHSmoothed are always 0.
+// The histogram is a single subtraction, so the saving is small and mostly
+// matters with WithNoHistory at very high update rates; with the histogram
+// smoothing it also saves an EMA update. Everything derived from SH, such as
+// IsExhausting, HistogramArea or HistogramPercentile, then sees zeros. MD, SB,
+// colors and crosses are not affected.
+func WithoutHistogram() Option {
+	return func(im *ImpulseMACD) error {
+		im.noHistogram = true
+		return nil
+	}
+}
+
+// WithExtremeBoost reports MD multiplied by factor in ImpulseValue.BoostedMD
+// on bars that close beyond the band by more than multiple band widths
+// (hi - lo), highlighting breakouts over marginal excursions. It measures the
+// close, not the source price, whatever WithColorSource selects; for
+// UpdateTypical the typical price stands in for the close. Other bars report
+// MD unchanged. The boost is for display and strength
+// only: MD, the signal, the histogram and crosses are not affected.
+func WithExtremeBoost(factor, multiple float64) Option {
+	return func(im *ImpulseMACD) error {
+		if factor <= 0 || math.IsNaN(factor) || math.IsInf(factor, 0) {
+			return fmt.Errorf("imacd: extreme boost factor must be positive and finite, got %v", factor)
+		}
+		if multiple < 0 || math.IsNaN(multiple) || math.IsInf(multiple, 0) {
+			return fmt.Errorf("imacd: extreme boost multiple must not be negative, got %v", multiple)
+		}
+		im.boost = &extremeBoost{factor, multiple}
+		return nil
+	}
+}
+
+// extremeBoost holds the settings of WithExtremeBoost
+type extremeBoost struct {
+	factor, multiple float64
+}
+
+// WithSampleStatistics selects the divisor used by the statistical helpers of
+// the indicator: n-1 (sample) when true, n (population) when false, the
+// default. It applies to every helper computing a variance or standard
+// deviation of the outputs; correlations do not depend on it as the divisor
+// cancels out. RollingStats used on its own is switched with SetSample.
+func WithSampleStatistics(sample bool) Option {
+	return func(im *ImpulseMACD) error {
+		im.sampleStats = sample
+		if im.shStats != nil {
+			im.shStats.SetSample(sample)
+		}
+		return nil
+	}
+}
+
+// UpdateChecked is like Update but rejects invalid prices without changing
+// any state
+func (im *ImpulseMACD) UpdateChecked(high, low, close float64) (ImpulseValue, error) {
+	for _, price := range [...]float64{high, low, close} {
+		if math.IsNaN(price) || math.IsInf(price, 0) {
+			return ImpulseValue{}, ErrInvalidPrice
+		}
+		if im.logPrice && price <= 0 {
+			return ImpulseValue{}, ErrNonPositivePrice
+		}
+	}
+	return im.Update(high, low, close), nil
+}
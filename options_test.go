@@ -0,0 +1,42 @@
+package imacd
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestExtremeBoostMeasuresClose checks that BoostedMD applies the factor
+// exactly when the close, rather than the HLC3 source, is more than the
+// multiple of band widths beyond a band
+func TestExtremeBoostMeasuresClose(t *testing.T) {
+	const factor, multiple = 3, 0.25
+	rng := rand.New(rand.NewPCG(13, 162))
+	bars := randomBars(rng, 400)
+	for i := range bars {
+		// Move the close within the range so it differs from HLC3
+		bars[i].Close = bars[i].Low + (bars[i].High-bars[i].Low)*rng.Float64()
+	}
+	im := newIndicator(t, WithExtremeBoost(factor, multiple), WithRetainBands())
+	values := im.BatchUpdate(bars)
+	his, los, _ := im.GetBandSeries()
+
+	var disagree int
+	for i, v := range values {
+		bar := bars[i]
+		byClose := colorIntensity(bar.Close, his[i], los[i]) > multiple
+		bySource := colorIntensity((bar.High+bar.Low+bar.Close)/3, his[i], los[i]) > multiple
+		want := v.MD
+		if byClose {
+			want *= factor
+		}
+		if v.BoostedMD != want {
+			t.Fatalf("bar %d: BoostedMD %v, want %v (boosted by close: %t)", i, v.BoostedMD, want, byClose)
+		}
+		if byClose != bySource && v.MD != 0 {
+			disagree++
+		}
+	}
+	if disagree == 0 {
+		t.Fatal("no bar with nonzero MD where the close and the source disagree")
+	}
+}
@@ -0,0 +1,47 @@
+package imacd
+
+import "sync"
+
+// BatchUpdateMany runs each symbol's bars through its own indicator on a pool
+// of at most workers goroutines and returns the values keyed by symbol. The
+// indicators are created from the receiver's configuration, which is left
+// untouched; those built from custom moving averages fall back to the
+// default ones, as custom instances cannot be shared. Workers below 1 are
+// treated as 1.
+func (im *ImpulseMACD) BatchUpdateMany(inputs map[string][]PriceBar, workers int) map[string][]ImpulseValue {
+	cfg := im.Config()
+	workers = max(1, min(workers, len(inputs)))
+
+	type result struct {
+		symbol string
+		values []ImpulseValue
+	}
+	symbols := make(chan string)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range symbols {
+				calc := newFromConfigOrDefault(cfg, im)
+				results <- result{symbol, calc.BatchUpdate(inputs[symbol])}
+			}
+		}()
+	}
+	go func() {
+		for symbol := range inputs {
+			symbols <- symbol
+		}
+		close(symbols)
+		wg.Wait()
+		close(results)
+	}()
+
+	out := make(map[string][]ImpulseValue, len(inputs))
+	for r := range results {
+		out[r.symbol] = r.values
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package imacd
+
+import "time"
+
+// MatchColorPattern reports whether the most recent bars end with the given
+// color sequence, oldest first. It returns false for an empty pattern or when
+// the pattern is longer than the calculated history.
+func (im *ImpulseMACD) MatchColorPattern(pattern []Color) bool {
+	if len(pattern) == 0 || len(pattern) > len(im.values) {
+		return false
+	}
+
+	offset := len(im.values) - len(pattern)
+	for i, color := range pattern {
+		if im.values[offset+i].Color != color {
+			return false
+		}
+	}
+	return true
+}
+
+// ColorRun is a contiguous segment of bars sharing the same color, with
+// inclusive Start and End indices into the calculated values and the
+// timestamps of those bars, zero when untimed
+type ColorRun struct {
+	Color     Color
+	Start     int
+	End       int
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// ColorRuns collapses the calculated values into runs of adjacent bars with
+// the same color, oldest first
+func (im *ImpulseMACD) ColorRuns() []ColorRun {
+	runs := make([]ColorRun, 0)
+	for i, v := range im.values {
+		if n := len(runs); n > 0 && runs[n-1].Color == v.Color {
+			runs[n-1].End = i
+			runs[n-1].EndTime = v.Timestamp
+			continue
+		}
+		runs = append(runs, ColorRun{
+			Color:     v.Color,
+			Start:     i,
+			End:       i,
+			StartTime: v.Timestamp,
+			EndTime:   v.Timestamp,
+		})
+	}
+	return runs
+}
+
+// GetColorAt returns the color at index i, with negative indexing as in
+// GetValueAt
+func (im *ImpulseMACD) GetColorAt(i int) (Color, bool) {
+	v, ok := im.GetValueAt(i)
+	return v.Color, ok
+}
+
+// GetColors returns a copy of the colors of all calculated values
+func (im *ImpulseMACD) GetColors() []Color {
+	colors := make([]Color, len(im.values))
+	for i, v := range im.values {
+		colors[i] = v.Color
+	}
+	return colors
+}
+
+// colorIntensity measures how far src is beyond the bands, normalized by the
+// band width: 0 inside the channel, 1 when a full width above the high band
+// or below the low band. It is 0 when the bands have no width.
+func colorIntensity(src, hi, lo float64) float64 {
+	width := hi - lo
+	if width <= 0 {
+		return 0
+	}
+	switch {
+	case src > hi:
+		return (src - hi) / width
+	case src < lo:
+		return (lo - src) / width
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,31 @@
+package imacd
+
+import (
+	"math"
+	"slices"
+)
+
+// HistogramPercentile returns the p-th percentile of SH over the last window
+// values, with p in [0, 100], interpolating linearly between the two closest
+// ranks. ok is false when p is out of range, window is below 1 or fewer than
+// window values are available.
+func (im *ImpulseMACD) HistogramPercentile(window int, p float64) (value float64, ok bool) {
+	if window < 1 || len(im.values) < window || !(p >= 0 && p <= 100) {
+		return 0, false
+	}
+
+	sorted := make([]float64, window)
+	for i, v := range im.values[len(im.values)-window:] {
+		sorted[i] = v.SH
+	}
+	slices.Sort(sorted)
+	return percentileSorted(sorted, p), true
+}
+
+// percentileSorted returns the p-th percentile of non-empty sorted values
+func percentileSorted(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := min(lo+1, len(sorted)-1)
+	return sorted[lo] + (rank-float64(lo))*(sorted[hi]-sorted[lo])
+}
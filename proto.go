@@ -0,0 +1,164 @@
+package imacd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// The protobuf encoding of values follows this schema, with proto3 semantics
+// (fields holding their zero value are omitted):
+//
+//	message ImpulseValue {
+//	  double md = 1;
+//	  double sb = 2;
+//	  double sh = 3;
+//	  string color = 4;
+//	  int64 timestamp_unix_nano = 5; // 0 when untimed
+//	}
+//
+//	message ImpulseValues {
+//	  repeated ImpulseValue values = 1;
+//	}
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+var errProtoTruncated = errors.New("imacd: protobuf data truncated")
+
+// MarshalValuesProto encodes values as an ImpulseValues protobuf message
+func MarshalValuesProto(values []ImpulseValue) ([]byte, error) {
+	var buf, msg []byte
+	for _, v := range values {
+		msg = appendValueProto(msg[:0], v)
+		buf = protoAppendTag(buf, 1, protoWireBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(msg)))
+		buf = append(buf, msg...)
+	}
+	return buf, nil
+}
+
+func appendValueProto(buf []byte, v ImpulseValue) []byte {
+	for i, f := range [...]float64{v.MD, v.SB, v.SH} {
+		if bits := math.Float64bits(f); bits != 0 {
+			buf = protoAppendTag(buf, i+1, protoWireFixed64)
+			buf = binary.LittleEndian.AppendUint64(buf, bits)
+		}
+	}
+	if v.Color != "" {
+		buf = protoAppendTag(buf, 4, protoWireBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(v.Color)))
+		buf = append(buf, v.Color...)
+	}
+	if !v.Timestamp.IsZero() {
+		buf = protoAppendTag(buf, 5, protoWireVarint)
+		buf = binary.AppendUvarint(buf, uint64(v.Timestamp.UnixNano()))
+	}
+	return buf
+}
+
+func protoAppendTag(buf []byte, field, wire int) []byte {
+	return binary.AppendUvarint(buf, uint64(field)<<3|uint64(wire))
+}
+
+// UnmarshalValuesProto decodes an ImpulseValues protobuf message. Unknown
+// fields are skipped; timestamps are returned in UTC.
+func UnmarshalValuesProto(data []byte) ([]ImpulseValue, error) {
+	values := make([]ImpulseValue, 0)
+	err := protoFields(data, func(field, wire int, raw []byte, n uint64) error {
+		if field != 1 || wire != protoWireBytes {
+			return nil
+		}
+		v, err := unmarshalValueProto(raw)
+		if err != nil {
+			return err
+		}
+		values = append(values, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func unmarshalValueProto(data []byte) (ImpulseValue, error) {
+	var v ImpulseValue
+	err := protoFields(data, func(field, wire int, raw []byte, n uint64) error {
+		switch {
+		case field >= 1 && field <= 3 && wire == protoWireFixed64:
+			f := math.Float64frombits(n)
+			switch field {
+			case 1:
+				v.MD = f
+			case 2:
+				v.SB = f
+			case 3:
+				v.SH = f
+			}
+		case field == 4 && wire == protoWireBytes:
+			v.Color = Color(raw)
+		case field == 5 && wire == protoWireVarint:
+			if n != 0 {
+				v.Timestamp = time.Unix(0, int64(n)).UTC()
+			}
+		}
+		return nil
+	})
+	return v, err
+}
+
+// protoFields walks the fields of a protobuf message, passing the payload of
+// length-delimited fields as raw and the value of the others as n
+func protoFields(data []byte, fn func(field, wire int, raw []byte, n uint64) error) error {
+	for len(data) > 0 {
+		tag, size := binary.Uvarint(data)
+		if size <= 0 {
+			return errProtoTruncated
+		}
+		data = data[size:]
+		field, wire := int(tag>>3), int(tag&7)
+
+		var raw []byte
+		var n uint64
+		switch wire {
+		case protoWireVarint:
+			n, size = binary.Uvarint(data)
+			if size <= 0 {
+				return errProtoTruncated
+			}
+			data = data[size:]
+		case protoWireFixed64:
+			if len(data) < 8 {
+				return errProtoTruncated
+			}
+			n = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case protoWireFixed32:
+			if len(data) < 4 {
+				return errProtoTruncated
+			}
+			n = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case protoWireBytes:
+			length, size := binary.Uvarint(data)
+			if size <= 0 || uint64(len(data)-size) < length {
+				return errProtoTruncated
+			}
+			raw = data[size : size+int(length)]
+			data = data[size+int(length):]
+		default:
+			return fmt.Errorf("imacd: unsupported protobuf wire type %d", wire)
+		}
+
+		if err := fn(field, wire, raw, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
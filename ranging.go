@@ -0,0 +1,19 @@
+package imacd
+
+import "math"
+
+// IsRanging reports whether |MD| stayed below threshold for each of the last
+// window values, a sign that the mid line is stuck inside the channel and
+// impulse signals are unreliable. It is false until window values are
+// available, and for windows below 1.
+func (im *ImpulseMACD) IsRanging(window int, threshold float64) bool {
+	if window < 1 || len(im.values) < window {
+		return false
+	}
+	for _, v := range im.values[len(im.values)-window:] {
+		if !(math.Abs(v.MD) < threshold) {
+			return false
+		}
+	}
+	return true
+}
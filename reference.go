@@ -0,0 +1,130 @@
+package imacd
+
+import (
+	"fmt"
+	"math"
+)
+
+// referenceTolerance is the relative tolerance between the streaming and the
+// reference outputs
+const referenceTolerance = 1e-9
+
+// referenceValue is a from-scratch result along with the inputs of the color
+// classification, so near-ties can be skipped when comparing colors
+type referenceValue struct {
+	ImpulseValue
+	src, hi, lo, mi float64
+}
+
+// VerifyAgainstReference runs the bars through a fresh streaming indicator
+// with the same lengths and checks every output against a naive reference
+// that recomputes each bar from scratch using the closed-form weights of the
+// SMMA and EMA instead of their recurrences. Options are not applied. The
+// reference is O(n²), so it is meant for tests and spot checks.
+func (im *ImpulseMACD) VerifyAgainstReference(bars []PriceBar) error {
+	if _, _, _, _, ok := im.builtins(); !ok {
+		return ErrCustomMovingAverage
+	}
+
+	streamed := NewImpulseMACD(im.lengthMA, im.lengthSignal).BatchUpdate(bars)
+	reference := referenceCompute(bars, im.lengthMA, im.lengthSignal)
+
+	for i, ref := range reference {
+		got := streamed[i]
+		for _, f := range [...]struct {
+			name      string
+			got, want float64
+		}{
+			{"MD", got.MD, ref.MD},
+			{"SB", got.SB, ref.SB},
+			{"SH", got.SH, ref.SH},
+		} {
+			if !withinTolerance(f.got, f.want) {
+				return fmt.Errorf("imacd: bar %d: %s is %v, reference %v", i, f.name, f.got, f.want)
+			}
+		}
+
+		// A color depends on strict comparisons, so only check it when the
+		// source is clearly away from the mid line and bands
+		if withinTolerance(ref.src, ref.mi) || withinTolerance(ref.src, ref.hi) || withinTolerance(ref.src, ref.lo) {
+			continue
+		}
+		if got.Color != ref.Color {
+			return fmt.Errorf("imacd: bar %d: color is %s, reference %s", i, got.Color, ref.Color)
+		}
+	}
+	return nil
+}
+
+func withinTolerance(a, b float64) bool {
+	scale := math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= referenceTolerance*scale
+}
+
+// referenceCompute calculates the indicator for every bar from scratch
+func referenceCompute(bars []PriceBar, lengthMA, lengthSignal int) []referenceValue {
+	highs := make([]float64, len(bars))
+	lows := make([]float64, len(bars))
+	srcs := make([]float64, len(bars))
+	for i, bar := range bars {
+		highs[i], lows[i] = bar.High, bar.Low
+		srcs[i] = (bar.High + bar.Low + bar.Close) / 3.0
+	}
+
+	smmaWeight := 1 / float64(lengthMA)
+	emaWeight := 2 / (float64(lengthMA) + 1)
+
+	ema1 := make([]float64, len(bars))
+	for i := range bars {
+		ema1[i] = referenceWeighted(srcs[:i+1], emaWeight)
+	}
+
+	results := make([]referenceValue, len(bars))
+	mds := make([]float64, len(bars))
+	for i := range bars {
+		hi := referenceWeighted(highs[:i+1], smmaWeight)
+		lo := referenceWeighted(lows[:i+1], smmaWeight)
+		e1 := ema1[i]
+		e2 := referenceWeighted(ema1[:i+1], emaWeight)
+		mi := e1 + (e1 - e2)
+
+		md := 0.0
+		if mi > hi {
+			md = mi - hi
+		} else if mi < lo {
+			md = mi - lo
+		}
+		mds[i] = md
+
+		start := max(0, i+1-lengthSignal)
+		sum := 0.0
+		for _, v := range mds[start : i+1] {
+			sum += v
+		}
+		sb := sum / float64(i+1-start)
+
+		src := srcs[i]
+		results[i] = referenceValue{
+			ImpulseValue: ImpulseValue{
+				MD:    md,
+				SB:    sb,
+				SH:    md - sb,
+				Color: classifyColor(Float(src).Cmp(Float(mi)), Float(src).Cmp(Float(hi)), Float(src).Cmp(Float(lo))),
+			},
+			src: src, hi: hi, lo: lo, mi: mi,
+		}
+	}
+	return results
+}
+
+// referenceWeighted evaluates an exponentially weighted average seeded with
+// the first value in closed form: the seed keeps (1-w)^n of the weight and
+// the k-th later value w(1-w)^(n-k)
+func referenceWeighted(xs []float64, w float64) float64 {
+	n := len(xs) - 1
+	sum := math.Pow(1-w, float64(n)) * xs[0]
+	for k := 1; k <= n; k++ {
+		sum += w * math.Pow(1-w, float64(n-k)) * xs[k]
+	}
+	return sum
+}
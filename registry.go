@@ -0,0 +1,99 @@
+package imacd
+
+import "sync"
+
+// Registry manages one indicator per symbol and is safe for concurrent use.
+// Updates to different symbols run in parallel; updates to the same symbol
+// are serialized.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]*registryEntry
+}
+
+type registryEntry struct {
+	mu sync.Mutex
+	im *ImpulseMACD
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]*registryEntry)}
+}
+
+// GetOrCreate returns the indicator for symbol, creating it with the given
+// lengths if it does not exist yet. The lengths are ignored for an existing
+// symbol. The returned indicator is shared: use Update or Do to access it
+// concurrently.
+func (r *Registry) GetOrCreate(symbol string, lengthMA, lengthSignal int) *ImpulseMACD {
+	return r.entry(symbol, lengthMA, lengthSignal).im
+}
+
+func (r *Registry) entry(symbol string, lengthMA, lengthSignal int) *registryEntry {
+	r.mu.RLock()
+	e, ok := r.entries[symbol]
+	r.mu.RUnlock()
+	if ok {
+		return e
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, ok := r.entries[symbol]; ok {
+		return e
+	}
+	e = &registryEntry{im: NewImpulseMACD(lengthMA, lengthSignal)}
+	r.entries[symbol] = e
+	return e
+}
+
+func (r *Registry) lookup(symbol string) (*registryEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[symbol]
+	return e, ok
+}
+
+// Update feeds a bar to the indicator for symbol. ok is false when the
+// symbol has not been created with GetOrCreate.
+func (r *Registry) Update(symbol string, bar PriceBar) (value ImpulseValue, ok bool) {
+	e, ok := r.lookup(symbol)
+	if !ok {
+		return ImpulseValue{}, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.im.updateBar(bar), true
+}
+
+// Do calls fn with the indicator for symbol while holding its lock, and
+// reports whether the symbol exists
+func (r *Registry) Do(symbol string, fn func(*ImpulseMACD)) bool {
+	e, ok := r.lookup(symbol)
+	if !ok {
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn(e.im)
+	return true
+}
+
+// Remove evicts the indicator for symbol
+func (r *Registry) Remove(symbol string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, symbol)
+}
+
+// Symbols returns the registered symbols in no particular order
+func (r *Registry) Symbols() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	symbols := make([]string, 0, len(r.entries))
+	for symbol := range r.entries {
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
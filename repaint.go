@@ -0,0 +1,32 @@
+package imacd
+
+import "math"
+
+// RepaintProfile measures how much the right edge of the series moves when
+// more bars arrive. It computes the bars with a fresh indicator of the same
+// configuration, computes them again without the last k bars, and returns,
+// for each of the last min(k, len(bars)-k) bars of the shorter run, the
+// absolute change of its MD between the two runs, oldest first. The receiver
+// is not updated, and custom moving averages fall back to the default ones.
+//
+// Every built-in line is causal, so a bar's values never depend on later
+// bars and the profile is all zeros: the indicator does not repaint closed
+// bars. The profile still makes that guarantee checkable for a given
+// configuration and data set. It returns nil when k is below 1 or not
+// smaller than len(bars).
+func (im *ImpulseMACD) RepaintProfile(bars []PriceBar, k int) []float64 {
+	if k < 1 || k >= len(bars) {
+		return nil
+	}
+
+	cfg := im.Config()
+	full := newFromConfigOrDefault(cfg, im).BatchUpdate(bars)
+	partial := newFromConfigOrDefault(cfg, im).BatchUpdate(bars[:len(bars)-k])
+
+	start := len(partial) - min(k, len(partial))
+	profile := make([]float64, 0, len(partial)-start)
+	for i := start; i < len(partial); i++ {
+		profile = append(profile, math.Abs(full[i].MD-partial[i].MD))
+	}
+	return profile
+}
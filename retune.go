@@ -0,0 +1,63 @@
+package imacd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// barInput is what update received for a bar, kept with WithRetainInputs
+type barInput struct {
+	t                time.Time
+	high, low, close float64
+	src              float64
+}
+
+func (b barInput) equal(other barInput) bool {
+	return b.t.Equal(other.t) && b.high == other.high && b.low == other.low &&
+		b.close == other.close && b.src == other.src
+}
+
+// WithRetainInputs stores the timestamp, high, low, close and source price of
+// every bar alongside the calculated values, subject to the same history
+// limit, so SetLengthMA can recompute them. It costs 56 bytes per bar.
+func WithRetainInputs() Option {
+	return func(im *ImpulseMACD) error {
+		im.retainInputs = true
+		return nil
+	}
+}
+
+// SetLengthMA changes the main length and recomputes the stored values from
+// the retained inputs, as the bands and the mid line have to be rerun from
+// the start. It needs WithRetainInputs and the built-in moving averages. The
+// replay costs one update per retained bar, O(n) in the history length, and
+// OnCross and OnValue handlers are not called for it. When a history limit
+// or TrimHistory has dropped older bars, the recomputation starts from the
+// oldest retained bar, so it only matches a full replay once the smoothing
+// has forgotten the difference. Active checkpoints are discarded.
+func (im *ImpulseMACD) SetLengthMA(n int) error {
+	im.checkReentry()
+	if n < 1 {
+		return fmt.Errorf("imacd: length must be positive, got %d", n)
+	}
+	if !im.retainInputs {
+		return errors.New("imacd: SetLengthMA needs WithRetainInputs")
+	}
+
+	cfg := im.Config()
+	cfg.LengthMA = n
+	if _, err := cfg.options(); err != nil {
+		return err
+	}
+	fresh := newFromConfigOrDefault(cfg, im)
+	for _, in := range im.inputs {
+		fresh.update(in.t, in.high, in.low, in.close, in.src)
+	}
+
+	fresh.crosses.handlers = im.crosses.handlers
+	fresh.valueHandlers = im.valueHandlers
+	fresh.subscribers = im.subscribers
+	*im = *fresh
+	return nil
+}
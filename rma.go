@@ -0,0 +1,138 @@
+package imacd
+
+import (
+	"errors"
+	"math"
+)
+
+// RMA mirrors TradingView's ta.rma: it is NaN (Pine's na) for the first
+// length-1 values, seeds with the SMA of the first length values, then
+// continues with alpha*src + (1-alpha)*prev where alpha = 1/length. The SMA
+// seed sums src/length from the most recent value back, following the Pine
+// reference implementation of ta.sma. NaN inputs are treated as na and skipped.
+type RMA struct {
+	length int
+	alpha  float64
+	window []float64
+	value  float64
+	count  int
+	saved  []rmaState
+}
+
+type rmaState struct {
+	window []float64
+	value  float64
+	count  int
+}
+
+// NewRMA creates a TradingView compatible RMA, lengths below 1 are treated
+// as 1
+func NewRMA(length int) *RMA {
+	length = max(length, 1)
+	return &RMA{
+		length: length,
+		alpha:  1 / float64(length),
+		window: make([]float64, 0, length),
+		value:  math.NaN(),
+	}
+}
+
+func (r *RMA) Update(value float64) float64 {
+	if math.IsNaN(value) {
+		return r.value
+	}
+	r.count++
+	if !r.IsReady() {
+		r.window = append(r.window, value)
+		if len(r.window) == r.length {
+			sum := 0.0
+			for i := len(r.window) - 1; i >= 0; i-- {
+				sum += r.window[i] / float64(r.length)
+			}
+			r.value = sum
+		}
+		return r.value
+	}
+	r.value = r.alpha*value + (1-r.alpha)*r.value
+	return r.value
+}
+
+func (r *RMA) Value() float64 {
+	return r.value
+}
+
+// Count returns the number of values seen since construction or Reset, not
+// counting NaN inputs
+func (r *RMA) Count() int {
+	return r.count
+}
+
+// IsReady reports whether the RMA has seen length values and is no longer na
+func (r *RMA) IsReady() bool {
+	return len(r.window) == r.length
+}
+
+func (r *RMA) Reset() {
+	r.window = r.window[:0]
+	r.value = math.NaN()
+	r.count = 0
+	r.saved = nil
+}
+
+func (r *RMA) push() {
+	window := make([]float64, len(r.window))
+	copy(window, r.window)
+	r.saved = append(r.saved, rmaState{window, r.value, r.count})
+}
+
+func (r *RMA) pop() {
+	st := r.saved[len(r.saved)-1]
+	r.saved = r.saved[:len(r.saved)-1]
+	r.window = append(r.window[:0], st.window...)
+	r.value, r.count = st.value, st.count
+}
+
+func (r *RMA) drop() {
+	r.saved = r.saved[:len(r.saved)-1]
+}
+
+// WithTradingViewRMA smooths the high and low bands with RMA, matching Pine's
+// ta.rma bit for bit instead of seeding from the first bar. While the bands
+// are na, MD is 0 and colors are green or orange, as comparisons against na
+// are false in Pine. Seeds, binary serialization and time decay need the
+// default SMMA bands and are not available with this option.
+func WithTradingViewRMA() Option {
+	return func(im *ImpulseMACD) error {
+		if im.customBands() {
+			return ErrCustomMovingAverage
+		}
+		if im.timeDecay > 0 {
+			return errors.New("imacd: TradingView RMA bands do not support time decay")
+		}
+		im.tvRMA = true
+		im.maHigh = im.newBand(im.bandLen())
+		im.maLow = im.newBand(im.bandLen())
+		return nil
+	}
+}
+
+// newBand creates a band moving average of the configured type
+func (im *ImpulseMACD) newBand(length int) MovingAverage {
+	if im.tvRMA {
+		return NewRMA(length)
+	}
+	return NewSMMA(length)
+}
+
+// customBands reports whether the bands were replaced by custom moving
+// averages
+func (im *ImpulseMACD) customBands() bool {
+	for _, ma := range []MovingAverage{im.maHigh, im.maLow} {
+		switch ma.(type) {
+		case *SMMA, *RMA:
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package imacd
+
+import (
+	"math"
+	"testing"
+)
+
+// nan marks the bars where Pine's ta.rma is still na
+var nan = math.NaN()
+
+// TestRMAMatchesPine checks RMA against ta.rma values worked out by hand from
+// the Pine reference implementation: na for length-1 bars, the SMA seed,
+// then alpha = 1/length
+func TestRMAMatchesPine(t *testing.T) {
+	src := []float64{10, 11, 12, 11, 13, 14, 12, 15}
+	tests := []struct {
+		length int
+		want   []float64
+	}{
+		{1, []float64{10, 11, 12, 11, 13, 14, 12, 15}},
+		{3, []float64{nan, nan, 11, 11, 35.0 / 3, 112.0 / 9, 332.0 / 27, 1069.0 / 81}},
+		{4, []float64{nan, nan, nan, 11, 11.5, 12.125, 12.09375, 12.8203125}},
+	}
+	for _, tt := range tests {
+		r := NewRMA(tt.length)
+		for i, v := range src {
+			got := r.Update(v)
+			if !closeOrBothNaN(got, tt.want[i]) {
+				t.Errorf("length %d bar %d: ta.rma = %v, got %v", tt.length, i, tt.want[i], got)
+			}
+			if ready := !math.IsNaN(tt.want[i]); r.IsReady() != ready {
+				t.Errorf("length %d bar %d: IsReady = %t, want %t", tt.length, i, r.IsReady(), ready)
+			}
+		}
+	}
+}
+
+// TestTradingViewRMAReference runs bars through WithTradingViewRMA and
+// checks MD, SB and colors against the LazyBear script evaluated with ta.rma
+// bands: while the bands are na MD is 0 and no bar is lime or red
+func TestTradingViewRMAReference(t *testing.T) {
+	tests := []struct {
+		high, low, close float64
+		md, sb           float64
+		color            Color
+	}{
+		{10, 9, 9.5, 0, 0, ColorOrange},
+		{11, 9.5, 10.5, 0, 0, ColorGreen},
+		{12, 10.5, 11.8, 0.15833333333333333, 0.07916666666666666, ColorLime},
+		{12.5, 11, 12.2, 0.33541666666666664, 0.246875, ColorLime},
+		{12, 10, 10.2, 0, 0.16770833333333332, ColorOrange},
+		{11, 8.5, 8.8, 0, 0, ColorRed},
+		{10, 8, 8.4, -0.04073431069958848, -0.02036715534979424, ColorRed},
+		{10, 9, 9.8, 0, -0.02036715534979424, ColorGreen},
+	}
+	im, err := NewImpulseMACDWithOptions(3, 2, WithTradingViewRMA())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		v := im.Update(tt.high, tt.low, tt.close)
+		if !closeOrBothNaN(v.MD, tt.md) || !closeOrBothNaN(v.SB, tt.sb) || v.Color != tt.color {
+			t.Errorf("bar %d: got MD %v SB %v %v, want MD %v SB %v %v", i, v.MD, v.SB, v.Color, tt.md, tt.sb, tt.color)
+		}
+	}
+}
+
+func closeOrBothNaN(got, want float64) bool {
+	if math.IsNaN(want) {
+		return math.IsNaN(got)
+	}
+	return math.Abs(got-want) <= 1e-12*math.Max(1, math.Abs(want))
+}
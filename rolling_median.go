@@ -0,0 +1,76 @@
+package imacd
+
+import (
+	"math"
+	"slices"
+)
+
+// RollingMedian tracks the median over a sliding window. Alongside the
+// window it keeps the values in sorted order, an order statistic found by
+// binary search, so an update costs O(log w) comparisons plus an O(w) copy
+// to insert and remove, which beats heaps with lazy deletion for the window
+// sizes used on prices.
+//
+// The window length must be odd so the median is always one of the values
+// seen rather than the mean of two; NewRollingMedian rounds even windows up.
+// Until the window is full the median is taken over the values seen so far,
+// averaging the two middle ones when their count is even; check Full before
+// relying on it.
+type RollingMedian struct {
+	window *SlidingWindow[float64]
+	sorted []float64
+}
+
+// NewRollingMedian creates a rolling median over the given window, windows
+// below 1 are treated as 1 and even windows are increased by one
+func NewRollingMedian(window int) *RollingMedian {
+	window = max(window, 1) | 1
+	return &RollingMedian{
+		window: NewSlidingWindow[float64](window),
+		sorted: make([]float64, 0, window),
+	}
+}
+
+// Update adds a value to the window and returns the updated median. NaN
+// values have no order, so they are skipped and the median is returned
+// unchanged.
+func (m *RollingMedian) Update(value float64) float64 {
+	if math.IsNaN(value) {
+		return m.Value()
+	}
+	if evicted, ok := m.window.Push(value); ok {
+		i, _ := slices.BinarySearch(m.sorted, evicted)
+		m.sorted = slices.Delete(m.sorted, i, i+1)
+	}
+	i, _ := slices.BinarySearch(m.sorted, value)
+	m.sorted = slices.Insert(m.sorted, i, value)
+	return m.Value()
+}
+
+// Value returns the current median, 0 before any value
+func (m *RollingMedian) Value() float64 {
+	n := len(m.sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return m.sorted[n/2]
+	}
+	return (m.sorted[n/2-1] + m.sorted[n/2]) / 2
+}
+
+// Count returns the number of values currently in the window
+func (m *RollingMedian) Count() int {
+	return len(m.sorted)
+}
+
+// Full reports whether the window holds window values
+func (m *RollingMedian) Full() bool {
+	return m.window.Full()
+}
+
+// Reset clears all internal state
+func (m *RollingMedian) Reset() {
+	m.window.Reset()
+	m.sorted = m.sorted[:0]
+}
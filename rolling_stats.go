@@ -0,0 +1,128 @@
+package imacd
+
+import (
+	"math"
+	"slices"
+)
+
+// RollingStats tracks the mean and variance over a sliding window using
+// Welford's algorithm, adding the newest value and removing the oldest. The
+// variance is the population variance unless SetSample switches it to the
+// sample variance.
+type RollingStats struct {
+	window int
+	values []float64
+	next   int
+	mean   float64
+	m2     float64
+	sample bool
+	saved  []rollingState
+}
+
+// NewRollingStats creates a rolling mean/variance helper over the given
+// window, windows below 1 are treated as 1
+func NewRollingStats(window int) *RollingStats {
+	if window < 1 {
+		window = 1
+	}
+	return &RollingStats{
+		window: window,
+		values: make([]float64, 0, window),
+	}
+}
+
+// SetSample selects the divisor of the variance: n-1 (sample) when true, n
+// (population) when false
+func (r *RollingStats) SetSample(sample bool) {
+	r.sample = sample
+}
+
+// Update adds a value to the window and returns the updated mean and
+// variance
+func (r *RollingStats) Update(value float64) (mean, variance float64) {
+	if len(r.values) < r.window {
+		r.values = append(r.values, value)
+		r.add(value)
+	} else {
+		oldest := r.values[r.next]
+		r.values[r.next] = value
+		r.next = (r.next + 1) % r.window
+		r.remove(oldest)
+		r.add(value)
+	}
+	return r.mean, r.Variance()
+}
+
+func (r *RollingStats) add(value float64) {
+	n := float64(len(r.values))
+	delta := value - r.mean
+	r.mean += delta / n
+	r.m2 += delta * (value - r.mean)
+}
+
+// remove drops a value from the running moments; len(r.values) still counts
+// the value being removed
+func (r *RollingStats) remove(value float64) {
+	n := float64(len(r.values) - 1)
+	if n == 0 {
+		r.mean, r.m2 = 0, 0
+		return
+	}
+	delta := value - r.mean
+	r.mean -= delta / n
+	r.m2 -= delta * (value - r.mean)
+	if r.m2 < 0 {
+		r.m2 = 0
+	}
+}
+
+// Count returns the number of values currently in the window
+func (r *RollingStats) Count() int {
+	return len(r.values)
+}
+
+// Full reports whether the window holds window values
+func (r *RollingStats) Full() bool {
+	return len(r.values) == r.window
+}
+
+// Mean returns the current mean
+func (r *RollingStats) Mean() float64 {
+	return r.mean
+}
+
+// Variance returns the current variance, 0 while there are too few values
+func (r *RollingStats) Variance() float64 {
+	n := len(r.values)
+	if r.sample {
+		n--
+	}
+	if n < 1 {
+		return 0
+	}
+	return r.m2 / float64(n)
+}
+
+// Std returns the current standard deviation
+func (r *RollingStats) Std() float64 {
+	return math.Sqrt(r.Variance())
+}
+
+// Reset clears all internal state
+func (r *RollingStats) Reset() {
+	r.values = r.values[:0]
+	r.next = 0
+	r.mean = 0
+	r.m2 = 0
+	r.saved = nil
+}
+
+// equal compares the window contents and moments of two helpers, either of
+// which may be nil
+func (r *RollingStats) equal(other *RollingStats) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.window == other.window && r.next == other.next && r.mean == other.mean &&
+		r.m2 == other.m2 && r.sample == other.sample && slices.Equal(r.values, other.values)
+}
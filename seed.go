@@ -0,0 +1,128 @@
+package imacd
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Seed holds the minimal recurrence state needed to continue an indicator
+// without its calculated history
+type Seed struct {
+	LengthMA     int
+	LengthSignal int
+
+	// Initialized reports whether the indicator has seen at least one bar
+	Initialized bool
+
+	// Count is the number of bars processed, used for warmup tracking
+	Count int
+
+	SMMAHigh float64
+	SMMALow  float64
+
+	// Inner EMAs of the ZLEMA mid line
+	MidEMA1 float64
+	MidEMA2 float64
+
+	// Signal holds the signal SMA window, oldest first
+	Signal []float64
+}
+
+// ExportSeed captures the sub-indicator state of the indicator, excluding
+// the calculated values
+func (im *ImpulseMACD) ExportSeed() (Seed, error) {
+	high, low, mid, sma, ok := im.builtins()
+	if !ok {
+		return Seed{}, ErrCustomMovingAverage
+	}
+
+	signal := sma.window.Values()
+
+	return Seed{
+		LengthMA:     im.lengthMA,
+		LengthSignal: im.lengthSignal,
+		Initialized:  high.isInit,
+		Count:        im.count,
+		SMMAHigh:     high.value,
+		SMMALow:      low.value,
+		MidEMA1:      mid.ema1.value,
+		MidEMA2:      mid.ema2.value,
+		Signal:       signal,
+	}, nil
+}
+
+// ImportSeed replaces the sub-indicator state with the given seed and clears
+// the calculated values. The seed must come from an indicator with the same
+// lengths.
+func (im *ImpulseMACD) ImportSeed(seed Seed) error {
+	high, low, mid, sma, ok := im.builtins()
+	if !ok {
+		return ErrCustomMovingAverage
+	}
+	if seed.LengthMA != im.lengthMA || seed.LengthSignal != im.lengthSignal {
+		return fmt.Errorf("imacd: seed lengths (%d, %d) do not match indicator (%d, %d)",
+			seed.LengthMA, seed.LengthSignal, im.lengthMA, im.lengthSignal)
+	}
+	if len(seed.Signal) > im.lengthSignal {
+		return fmt.Errorf("imacd: seed signal window has %d values, want at most %d",
+			len(seed.Signal), im.lengthSignal)
+	}
+
+	im.Reset()
+	if !seed.Initialized {
+		return nil
+	}
+
+	high.value, high.isInit = seed.SMMAHigh, true
+	low.value, low.isInit = seed.SMMALow, true
+	mid.ema1.value, mid.ema1.isInit = seed.MidEMA1, true
+	mid.ema2.value, mid.ema2.isInit = seed.MidEMA2, true
+	mid.value = seed.MidEMA1 + (seed.MidEMA1 - seed.MidEMA2)
+	for _, v := range seed.Signal {
+		sma.Update(v)
+	}
+	im.count = seed.Count
+	setCounts(seed.Count, high, low, mid, sma)
+	return nil
+}
+
+// SeedState primes the indicator from the last known recurrence values, the
+// lightest warm start when neither the history nor an exported Seed is at
+// hand. The bands and the inner EMAs of the mid line take the given values,
+// the signal window is filled with signal, and the bar count is set to
+// MinBars so the next value is valid; the calculated values are cleared.
+// The values must be finite and, with WithLogPrice, in log space.
+//
+// Nothing checks that the values belong together: seeds taken at different
+// bars, or from an indicator with other lengths or options, produce outputs
+// that are wrong from the first bar and only converge to those of a full
+// replay as the smoothing forgets them, roughly WarmStartBars bars later.
+// Prefer ExportSeed and ImportSeed when the state can be captured.
+func (im *ImpulseMACD) SeedState(smmaHigh, smmaLow, midEMA1, midEMA2, signal float64) error {
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"smmaHigh", smmaHigh},
+		{"smmaLow", smmaLow},
+		{"midEMA1", midEMA1},
+		{"midEMA2", midEMA2},
+		{"signal", signal},
+	} {
+		if math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			return fmt.Errorf("imacd: seed %s is %v", f.name, f.value)
+		}
+	}
+	return im.ImportSeed(Seed{
+		LengthMA:     im.lengthMA,
+		LengthSignal: im.lengthSignal,
+		Initialized:  true,
+		Count:        im.MinBars(),
+		SMMAHigh:     smmaHigh,
+		SMMALow:      smmaLow,
+		MidEMA1:      midEMA1,
+		MidEMA2:      midEMA2,
+		Signal:       slices.Repeat([]float64{signal}, im.lengthSignal),
+	})
+}
@@ -0,0 +1,43 @@
+package imacd
+
+// Signal is a discrete trading action derived from the crossovers
+type Signal int
+
+const (
+	SignalHold Signal = iota // No crossover reported at this bar
+	SignalBuy                // MD crossed above the signal
+	SignalSell               // MD crossed below the signal
+)
+
+// String returns the name of the signal
+func (s Signal) String() string {
+	switch s {
+	case SignalBuy:
+		return "buy"
+	case SignalSell:
+		return "sell"
+	default:
+		return "hold"
+	}
+}
+
+// UpdateWithSignal is like Update but also returns the action for the bar:
+// SignalBuy when it reported an upward MD/SB crossover, SignalSell for a
+// downward one and SignalHold otherwise. The crossovers are those passed to
+// OnCross handlers, so the plateau rules of LastCrossInfo and the
+// WithCrossDebounce gap apply; set the debounce to change how often signals
+// may repeat. Bars before the warmup completes always hold, as their values
+// are only seeds.
+func (im *ImpulseMACD) UpdateWithSignal(high, low, close float64) (ImpulseValue, Signal) {
+	value := im.Update(high, low, close)
+	if !value.Valid {
+		return value, SignalHold
+	}
+	switch {
+	case im.crosses.lastAt[CrossUp] == im.count:
+		return value, SignalBuy
+	case im.crosses.lastAt[CrossDown] == im.count:
+		return value, SignalSell
+	}
+	return value, SignalHold
+}
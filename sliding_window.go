@@ -0,0 +1,99 @@
+package imacd
+
+import "iter"
+
+// SlidingWindow is a fixed-size ring buffer holding the most recent values
+// pushed to it, oldest first
+type SlidingWindow[T any] struct {
+	buf   []T
+	start int
+	n     int
+}
+
+// NewSlidingWindow creates a window holding up to size values, sizes below 1
+// are treated as 1
+func NewSlidingWindow[T any](size int) *SlidingWindow[T] {
+	return &SlidingWindow[T]{buf: make([]T, max(size, 1))}
+}
+
+// Push adds v as the newest value. When the window is full the oldest value
+// is evicted and returned with ok true.
+func (w *SlidingWindow[T]) Push(v T) (evicted T, ok bool) {
+	if w.n < len(w.buf) {
+		w.buf[(w.start+w.n)%len(w.buf)] = v
+		w.n++
+		return evicted, false
+	}
+	evicted = w.buf[w.start]
+	w.buf[w.start] = v
+	w.start = (w.start + 1) % len(w.buf)
+	return evicted, true
+}
+
+// Len returns the number of values in the window
+func (w *SlidingWindow[T]) Len() int {
+	return w.n
+}
+
+// Size returns the maximum number of values the window holds
+func (w *SlidingWindow[T]) Size() int {
+	return len(w.buf)
+}
+
+// Full reports whether the window holds Size values
+func (w *SlidingWindow[T]) Full() bool {
+	return w.n == len(w.buf)
+}
+
+// Oldest returns the oldest value, or the zero value when the window is empty
+func (w *SlidingWindow[T]) Oldest() T {
+	var zero T
+	if w.n == 0 {
+		return zero
+	}
+	return w.buf[w.start]
+}
+
+// Newest returns the newest value, or the zero value when the window is empty
+func (w *SlidingWindow[T]) Newest() T {
+	var zero T
+	if w.n == 0 {
+		return zero
+	}
+	return w.At(w.n - 1)
+}
+
+// At returns the i-th value, counting from the oldest; it panics when i is
+// out of range
+func (w *SlidingWindow[T]) At(i int) T {
+	if i < 0 || i >= w.n {
+		panic("imacd: sliding window index out of range")
+	}
+	return w.buf[(w.start+i)%len(w.buf)]
+}
+
+// All iterates over the index and value of every value, oldest first
+func (w *SlidingWindow[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := range w.n {
+			if !yield(i, w.buf[(w.start+i)%len(w.buf)]) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns a copy of the values, oldest first
+func (w *SlidingWindow[T]) Values() []T {
+	values := make([]T, 0, w.n)
+	for _, v := range w.All() {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Reset empties the window
+func (w *SlidingWindow[T]) Reset() {
+	clear(w.buf)
+	w.start, w.n = 0, 0
+}
@@ -0,0 +1,47 @@
+package imacd
+
+import "math"
+
+// SignalSlope returns the per-bar rate of change of the signal line, as the
+// least-squares slope of the last lookback+1 SB values against their bar
+// index. With lookback 1 this is the change from the previous bar. It returns
+// NaN when lookback is below 1 or fewer than lookback+1 values are available.
+func (im *ImpulseMACD) SignalSlope(lookback int) float64 {
+	if lookback < 1 || len(im.values) < lookback+1 {
+		return math.NaN()
+	}
+
+	window := im.values[len(im.values)-lookback-1:]
+	return linearSlope(len(window), func(i int) float64 { return window[i].SB })
+}
+
+// MidSlope returns the least-squares slope per bar of the mid line over the
+// last lookback bars, a directional filter on the trend. It needs
+// WithRetainBands; ok is false without it, when lookback is below 2 or when
+// fewer than lookback bars are retained.
+func (im *ImpulseMACD) MidSlope(lookback int) (slope float64, ok bool) {
+	if !im.retainBands || lookback < 2 || len(im.bands) < lookback {
+		return 0, false
+	}
+	window := im.bands[len(im.bands)-lookback:]
+	return linearSlope(len(window), func(i int) float64 { return window[i].Mid }), true
+}
+
+// linearSlope fits y(i) for i in [0, n) by least squares and returns the
+// slope, centering both axes first so large price levels do not cancel out
+func linearSlope(n int, y func(int) float64) float64 {
+	meanX := float64(n-1) / 2
+	meanY := 0.0
+	for i := range n {
+		meanY += y(i)
+	}
+	meanY /= float64(n)
+
+	var sxy, sxx float64
+	for i := range n {
+		dx := float64(i) - meanX
+		sxy += dx * (y(i) - meanY)
+		sxx += dx * dx
+	}
+	return sxy / sxx
+}
@@ -0,0 +1,149 @@
+package imacd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrStateNotFound is returned by StateStore.Load for unknown keys
+var ErrStateNotFound = errors.New("imacd: state not found")
+
+// StateStore persists indicators under a key, for warm starts across
+// restarts. The included stores keep the JSON snapshot of MarshalJSON, so the
+// same limitations apply, and Load always returns a new, independent
+// indicator.
+type StateStore interface {
+	Save(key string, im *ImpulseMACD) error
+	Load(key string) (*ImpulseMACD, error)
+}
+
+// MemoryStore is a StateStore keeping snapshots in memory, safe for
+// concurrent use
+type MemoryStore struct {
+	mu     sync.RWMutex
+	states map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string][]byte)}
+}
+
+// Save stores a snapshot of im under key, replacing any previous one
+func (s *MemoryStore) Save(key string, im *ImpulseMACD) error {
+	data, err := im.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = data
+	return nil
+}
+
+// Load restores the indicator saved under key
+func (s *MemoryStore) Load(key string) (*ImpulseMACD, error) {
+	s.mu.RLock()
+	data, ok := s.states[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrStateNotFound, key)
+	}
+	return decodeSnapshot(data)
+}
+
+// FileStore is a StateStore keeping one JSON file per key in a directory.
+// Files are synced to disk and replaced atomically, so a crash during Save
+// leaves the previous snapshot intact, and a crash after it keeps the new
+// one. Keys must be valid file names without path separators.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Save writes a snapshot of im to the file for key
+func (s *FileStore) Save(key string, im *ImpulseMACD) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	data, err := im.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "."+key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Without the sync the rename can reach the disk before the data, and a
+	// crash would leave an empty or partial file under the key
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(s.dir)
+}
+
+// syncDir flushes the directory entry of a rename to disk. Windows cannot
+// sync directories and makes renames durable without it.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// Load restores the indicator from the file for key
+func (s *FileStore) Load(key string) (*ImpulseMACD, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrStateNotFound, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshot(data)
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("imacd: invalid state key %q", key)
+	}
+	return filepath.Join(s.dir, key+".json"), nil
+}
@@ -0,0 +1,72 @@
+package imacd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Snapshots drop the cross tracking, as MarshalJSON does, so compare
+	// against a load from the memory store
+	mem := NewMemoryStore()
+	im := NewImpulseMACD(8, 5)
+	for i, bars := range []int{10, 25} {
+		im.BatchUpdate(sineBars(bars))
+		if err := s.Save("btc", im); err != nil {
+			t.Fatalf("save %d: %v", i, err)
+		}
+		if err := mem.Save("btc", im); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := s.Load("btc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := mem.Load("btc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.Equal(want) || loaded.count != im.count {
+			t.Fatalf("save %d: loaded indicator differs", i)
+		}
+	}
+
+	// Only the snapshot is left, no temporary files
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "btc.json" {
+		t.Fatalf("store directory holds %v, want only btc.json", entries)
+	}
+
+	if _, err := s.Load("eth"); !errors.Is(err, ErrStateNotFound) {
+		t.Fatalf("Load unknown key = %v, want ErrStateNotFound", err)
+	}
+	for _, key := range []string{"", "..", ".hidden", "a/b", `a\b`} {
+		if err := s.Save(key, im); err == nil {
+			t.Fatalf("Save accepted the key %q", key)
+		}
+	}
+}
+
+func TestFileStoreSaveFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("btc", NewImpulseMACD(8, 5)); err == nil {
+		t.Fatal("Save into a removed directory succeeded")
+	}
+}
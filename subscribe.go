@@ -0,0 +1,167 @@
+package imacd
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// OnValue registers a handler called with every committed value at the end
+// of Update and its variants, after OnCross handlers. Handlers run in
+// registration order on the updating goroutine. Values previewed with
+// UpdateForming are not passed to them. Handlers may read the indicator;
+// mutating it from a handler panics.
+func (im *ImpulseMACD) OnValue(fn func(ImpulseValue)) {
+	im.valueHandlers = append(im.valueHandlers, fn)
+}
+
+// SubscriberPolicy selects what happens when a subscriber's channel is full
+type SubscriberPolicy int
+
+const (
+	// SubscriberDrop skips the value for a subscriber whose buffer is full,
+	// so a slow consumer misses values but never delays the updates
+	SubscriberDrop SubscriberPolicy = iota
+	// SubscriberBlock waits until every subscriber has room for the value,
+	// so no values are missed but each update waits for the slowest consumer
+	SubscriberBlock
+)
+
+// defaultSubscriberBuffer is the channel capacity used by Subscribe unless
+// WithSubscriberPolicy sets another one
+const defaultSubscriberBuffer = 64
+
+// broadcaster fans committed values out to the channels returned by
+// Subscribe. It is shared by pointer, so it survives restores, and guarded by
+// its own lock, as subscribers come and go on other goroutines.
+type broadcaster struct {
+	mu     sync.RWMutex
+	subs   []*subscriber
+	active atomic.Int32 // len(subs), read by notify without taking mu
+	policy SubscriberPolicy
+	buffer int
+}
+
+type subscriber struct {
+	ch   chan ImpulseValue
+	done chan struct{}
+	stop sync.Once // Closes done and ch, once however many Unsubscribe calls race
+}
+
+// WithSubscriberPolicy sets how Subscribe channels handle slow consumers and
+// their capacity; a buffer below 1 uses the default of 64
+func WithSubscriberPolicy(policy SubscriberPolicy, buffer int) Option {
+	return func(im *ImpulseMACD) error {
+		if policy != SubscriberDrop && policy != SubscriberBlock {
+			return fmt.Errorf("imacd: unknown subscriber policy %d", policy)
+		}
+		im.subscribers.policy = policy
+		im.subscribers.buffer = max(buffer, 0)
+		return nil
+	}
+}
+
+// Subscribe returns a buffered channel receiving every committed value, in
+// the same cases as OnValue handlers and after them. Subscribe and
+// Unsubscribe may be called from any goroutine, including while another one
+// updates the indicator. With the default SubscriberDrop policy a consumer
+// that falls behind by more than the buffer misses values; with
+// SubscriberBlock the updating goroutine waits for it instead. Each
+// subscriber must call Unsubscribe once done, which closes the channel.
+func (im *ImpulseMACD) Subscribe() <-chan ImpulseValue {
+	b := im.subscribers
+	size := b.buffer
+	if size == 0 {
+		size = defaultSubscriberBuffer
+	}
+	s := &subscriber{ch: make(chan ImpulseValue, size), done: make(chan struct{})}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, s)
+	b.active.Store(int32(len(b.subs)))
+	b.mu.Unlock()
+	return s.ch
+}
+
+// Unsubscribe stops delivery to a channel returned by Subscribe and closes
+// it. An update blocked on the channel under SubscriberBlock is released.
+// Unknown or already unsubscribed channels are ignored.
+func (im *ImpulseMACD) Unsubscribe(ch <-chan ImpulseValue) {
+	b := im.subscribers
+	b.mu.RLock()
+	i := slices.IndexFunc(b.subs, func(s *subscriber) bool { return s.ch == ch })
+	var s *subscriber
+	if i >= 0 {
+		s = b.subs[i]
+	}
+	b.mu.RUnlock()
+	if s == nil {
+		return
+	}
+
+	s.stop.Do(func() {
+		// Once done is closed, publish no longer waits on the channel, so the
+		// write lock is only held up by sends to other subscribers
+		close(s.done)
+		b.mu.Lock()
+		b.subs = slices.DeleteFunc(b.subs, func(other *subscriber) bool { return other == s })
+		b.active.Store(int32(len(b.subs)))
+		close(s.ch)
+		b.mu.Unlock()
+	})
+}
+
+// publish sends a value to every subscriber according to the policy
+func (b *broadcaster) publish(v ImpulseValue) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subs {
+		if b.policy == SubscriberBlock {
+			select {
+			case s.ch <- v:
+			case <-s.done:
+			}
+			continue
+		}
+		select {
+		case s.ch <- v:
+		case <-s.done:
+		default:
+		}
+	}
+}
+
+// notify reports the committed value to the cross tracking, the OnCross and
+// OnValue handlers and the subscribers. Handlers may read the indicator but
+// not mutate it: updates, resets, checkpoints, restores and history changes
+// made from a handler panic, as they would corrupt the update in progress.
+func (im *ImpulseMACD) notify(hasPrev bool, prev, value ImpulseValue) {
+	if len(im.valueHandlers) == 0 && len(im.crosses.handlers) == 0 && im.subscribers.active.Load() == 0 {
+		// Nothing can call back into the indicator, so skip the guard and the
+		// subscriber lock and only keep the cross tracking up to date
+		if hasPrev {
+			im.crosses.observe(im, prev, value)
+		}
+		return
+	}
+	im.notifying = true
+	defer func() { im.notifying = false }()
+	if hasPrev {
+		im.crosses.observe(im, prev, value)
+	}
+	for _, fn := range im.valueHandlers {
+		fn(value)
+	}
+	if !im.muted {
+		im.subscribers.publish(value)
+	}
+}
+
+// checkReentry panics when a mutating method is called from an OnCross or
+// OnValue handler of the same indicator
+func (im *ImpulseMACD) checkReentry() {
+	if im.notifying {
+		panic("imacd: indicator mutated from an OnCross or OnValue handler")
+	}
+}
@@ -0,0 +1,99 @@
+package imacd
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// TestNotifyFastPathKeepsCrosses checks that an indicator with no handlers
+// or subscribers, which skips the notify guard, still tracks crosses like
+// one with handlers attached
+func TestNotifyFastPathKeepsCrosses(t *testing.T) {
+	bare := NewImpulseMACD(8, 5)
+	watched := NewImpulseMACD(8, 5)
+	var crosses, values int
+	watched.OnCross(func(CrossType, ImpulseValue) { crosses++ })
+	watched.OnValue(func(ImpulseValue) { values++ })
+
+	bars := randomBars(rand.New(rand.NewPCG(1, 172)), 300)
+	for i, bar := range bars {
+		bare.Update(bar.High, bar.Low, bar.Close)
+		watched.Update(bar.High, bar.Low, bar.Close)
+		if bare.LastCross() != watched.LastCross() {
+			t.Fatalf("bar %d: LastCross %v without handlers, %v with", i, bare.LastCross(), watched.LastCross())
+		}
+	}
+	if crosses == 0 || values != len(bars) {
+		t.Fatalf("handlers saw %d crosses and %d values, want some crosses and %d values", crosses, values, len(bars))
+	}
+	if !bare.Equal(watched) {
+		t.Fatal("indicators differ with and without handlers")
+	}
+}
+
+func TestSubscribeAfterFastPath(t *testing.T) {
+	im := NewImpulseMACD(8, 5)
+	im.Update(10, 9, 9.5)
+	ch := im.Subscribe()
+	v := im.Update(11, 10, 10.5)
+	if got := <-ch; got != v {
+		t.Fatalf("subscriber got %+v, want %+v", got, v)
+	}
+	im.Unsubscribe(ch)
+	if n := im.subscribers.active.Load(); n != 0 {
+		t.Fatalf("active subscribers after Unsubscribe = %d, want 0", n)
+	}
+	im.Update(12, 11, 11.5)
+	if _, open := <-ch; open {
+		t.Fatal("channel still open after Unsubscribe")
+	}
+}
+
+// TestHandlerReentry checks that mutating the indicator from a handler
+// panics while reading it works, and that the indicator is usable once the
+// panic is recovered
+func TestHandlerReentry(t *testing.T) {
+	mutations := map[string]func(*ImpulseMACD){
+		"Update":         func(im *ImpulseMACD) { im.Update(11, 9, 10) },
+		"ResetState":     func(im *ImpulseMACD) { im.ResetState(false) },
+		"Reset":          func(im *ImpulseMACD) { im.Reset() },
+		"PushCheckpoint": func(im *ImpulseMACD) { im.PushCheckpoint() },
+		"TrimHistory":    func(im *ImpulseMACD) { im.TrimHistory(1) },
+	}
+	for name, mutate := range mutations {
+		t.Run(name, func(t *testing.T) {
+			im := NewImpulseMACD(8, 5)
+			var latest *ImpulseValue
+			armed := true
+			im.OnValue(func(ImpulseValue) {
+				latest = im.GetLatest()
+				if armed {
+					armed = false
+					mutate(im)
+				}
+			})
+
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Fatalf("%s from a handler did not panic", name)
+					}
+				}()
+				im.Update(10, 9, 9.5)
+			}()
+			if latest == nil || im.count != 1 {
+				t.Fatalf("handler read %v and count is %d, want the committed value and 1", latest, im.count)
+			}
+
+			// The guard is cleared by the panic, so the indicator carries on
+			want := NewImpulseMACD(8, 5)
+			want.Update(10, 9, 9.5)
+			if got, exp := im.Update(11, 9, 10), want.Update(11, 9, 10); got != exp {
+				t.Fatalf("after recovering: %+v, want %+v", got, exp)
+			}
+			if *latest != *want.GetLatest() {
+				t.Fatalf("handler read %+v, want %+v", *latest, *want.GetLatest())
+			}
+		})
+	}
+}
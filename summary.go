@@ -0,0 +1,99 @@
+package imacd
+
+import "math"
+
+// RunSummary is an overview of the calculated values
+type RunSummary struct {
+	Bars        int
+	ColorCounts map[Color]int
+
+	CrossesUp   int
+	CrossesDown int
+
+	MaxMD float64
+	MinMD float64
+	MaxSH float64
+	MinSH float64
+
+	// Index of the histogram bar with the largest magnitude, -1 when empty
+	LargestSHIndex int
+}
+
+// Summary computes a RunSummary from the stored values. Crosses are counted
+// from the raw values, as LastCrossInfo does.
+func (im *ImpulseMACD) Summary() RunSummary {
+	summary := RunSummary{
+		Bars:           len(im.values),
+		ColorCounts:    make(map[Color]int),
+		LargestSHIndex: -1,
+	}
+	if len(im.values) == 0 {
+		return summary
+	}
+
+	summary.MaxMD, summary.MinMD = math.Inf(-1), math.Inf(1)
+	summary.MaxSH, summary.MinSH = math.Inf(-1), math.Inf(1)
+	largest := -1.0
+	for i, v := range im.values {
+		summary.ColorCounts[v.Color]++
+		summary.MaxMD = math.Max(summary.MaxMD, v.MD)
+		summary.MinMD = math.Min(summary.MinMD, v.MD)
+		summary.MaxSH = math.Max(summary.MaxSH, v.SH)
+		summary.MinSH = math.Min(summary.MinSH, v.SH)
+		if abs := math.Abs(v.SH); abs > largest {
+			largest = abs
+			summary.LargestSHIndex = i
+		}
+
+		if i == 0 {
+			continue
+		}
+		switch crossAt(im.values, i) {
+		case CrossUp:
+			summary.CrossesUp++
+		case CrossDown:
+			summary.CrossesDown++
+		}
+	}
+	return summary
+}
+
+// SummarySnapshot is a fixed-size overview of the latest values, small
+// enough to emit periodically to a metrics pipeline
+type SummarySnapshot struct {
+	Bars   int `json:"bars"` // Values counted, at most the window
+	Lime   int `json:"lime"`
+	Green  int `json:"green"`
+	Red    int `json:"red"`
+	Orange int `json:"orange"`
+
+	LastCross CrossType `json:"cross"` // As reported by LastCross
+	Strength  float64   `json:"strength"`
+	BandWidth float64   `json:"band_width"` // 0 until warmed up
+}
+
+// RollingSummary returns a SummarySnapshot over the last window values. The
+// color counts cover the window, clamped to the stored values; the strength
+// is the latest histogram value, signed like the move it measures, whatever
+// the window. It costs O(window).
+func (im *ImpulseMACD) RollingSummary(window int) SummarySnapshot {
+	snap := SummarySnapshot{LastCross: im.LastCross()}
+	for v := range im.TailView(window) {
+		snap.Bars++
+		switch v.Color {
+		case ColorLime:
+			snap.Lime++
+		case ColorGreen:
+			snap.Green++
+		case ColorRed:
+			snap.Red++
+		case ColorOrange:
+			snap.Orange++
+		}
+	}
+	if n := len(im.values); n > 0 {
+		snap.Strength = im.values[n-1].SH
+	}
+	snap.BandWidth, _ = im.BandWidth()
+	return snap
+}
@@ -0,0 +1,99 @@
+package imacd
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrOutOfOrder is returned by UpdateAt when a timestamp is before the
+// previous bar's
+var ErrOutOfOrder = errors.New("imacd: bar timestamp is before the previous bar")
+
+// timeDecay switches an SMMA or EMA from its length-based weight to one
+// derived from the time elapsed since the previous sample: a sample arriving
+// one halflife later gets half the weight, so alpha = 1 - 2^(-dt/halflife)
+type timeDecay struct {
+	halflife time.Duration
+	elapsed  time.Duration
+	timed    bool
+}
+
+// setElapsed records the time elapsed before the next sample
+func (d *timeDecay) setElapsed(dt time.Duration) {
+	if d.halflife > 0 {
+		d.elapsed, d.timed = dt, true
+	}
+}
+
+// alpha returns the weight of the next sample, and false when the sample is
+// untimed and the length-based weight applies
+func (d *timeDecay) alpha() (float64, bool) {
+	if !d.timed {
+		return 0, false
+	}
+	d.timed = false
+	return 1 - math.Exp2(-float64(d.elapsed)/float64(d.halflife)), true
+}
+
+// elapsedSetter is implemented by the moving averages supporting time decay
+type elapsedSetter interface {
+	setElapsed(dt time.Duration)
+}
+
+func (s *SMMA) setElapsed(dt time.Duration) { s.decay.setElapsed(dt) }
+func (e *EMA) setElapsed(dt time.Duration)  { e.decay.setElapsed(dt) }
+
+func (z *ZLEMA) setElapsed(dt time.Duration) {
+	z.ema1.setElapsed(dt)
+	z.ema2.setElapsed(dt)
+}
+
+// advanceTime passes the time elapsed since the previous timed bar to the
+// bands and mid line when time decay is enabled. Timestamps before the
+// previous one count as no elapsed time.
+func (im *ImpulseMACD) advanceTime(t time.Time) {
+	if im.timeDecay > 0 && im.count > 0 && !im.lastTime.IsZero() {
+		dt := max(0, t.Sub(im.lastTime))
+		for _, ma := range []MovingAverage{im.maHigh, im.maLow, im.maMid} {
+			if es, ok := ma.(elapsedSetter); ok {
+				es.setElapsed(dt)
+			}
+		}
+	}
+	if t.After(im.lastTime) {
+		im.lastTime = t
+	}
+}
+
+// WithTimeDecay weights the bands and the mid line by the time elapsed
+// between bars instead of by bar count, for irregularly spaced bars. The
+// recurrences become EMAs with alpha = 1 - 2^(-dt/halflife). It applies to
+// bars updated with UpdateAt or carrying a PriceBar.Time; untimed updates
+// keep the length-based weights. The signal SMA stays count based.
+func WithTimeDecay(halflife time.Duration) Option {
+	return func(im *ImpulseMACD) error {
+		if halflife <= 0 {
+			return errors.New("imacd: time decay halflife must be positive")
+		}
+		if _, _, _, _, ok := im.builtins(); !ok {
+			return ErrCustomMovingAverage
+		}
+		im.timeDecay = halflife
+		im.applyTimeDecay()
+		return nil
+	}
+}
+
+// applyTimeDecay sets the configured halflife on the built-in bands and mid
+// line
+func (im *ImpulseMACD) applyTimeDecay() {
+	high, low, mid, _, ok := im.builtins()
+	if !ok || im.timeDecay == 0 {
+		return
+	}
+	high.decay.halflife = im.timeDecay
+	low.decay.halflife = im.timeDecay
+	mid.ema1.decay.halflife = im.timeDecay
+	mid.ema2.decay.halflife = im.timeDecay
+}
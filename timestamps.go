@@ -0,0 +1,105 @@
+package imacd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateTimestamp is returned for a bar stamped with the same time as
+// the previous one when the timestamp policy does not accept it
+var ErrDuplicateTimestamp = errors.New("imacd: duplicate timestamp")
+
+// TimestampPolicy selects how timed bars that repeat or go back in time are
+// handled, see WithTimestampPolicy
+type TimestampPolicy int
+
+const (
+	// TimestampAllow processes repeated timestamps as separate bars. UpdateAt
+	// rejects earlier timestamps, and the batch methods process them as if
+	// no time had elapsed. This is the default.
+	TimestampAllow TimestampPolicy = iota
+	// TimestampReject rejects repeated and earlier timestamps
+	TimestampReject
+	// TimestampMerge merges a bar with the same timestamp as the previous one
+	// into it, like Aggregate: the first open, the highest high, the lowest
+	// low and the latest close. Earlier timestamps are rejected.
+	TimestampMerge
+)
+
+// WithTimestampPolicy sets how timed bars with repeated or earlier timestamps
+// are handled. Under TimestampReject and TimestampMerge every timed value is
+// stamped strictly later than the one before, as GetValuesSince assumes, and
+// the time decay never sees a zero or negative elapsed time.
+//
+// UpdateAt returns ErrDuplicateTimestamp or ErrOutOfOrder for rejected bars.
+// The batch methods cannot return errors, so they skip rejected bars and
+// return a zero ImpulseValue for them. Updates without a timestamp are not
+// affected.
+//
+// TimestampMerge recomputes the previous bar from a checkpoint saved before
+// each timed bar, so it needs the built-in moving averages, and the merged
+// value replaces the previous one in the history. OnCross handlers already
+// called for the replaced value are not revoked. PushCheckpoint,
+// PopCheckpoint and Reset end the merge window, so a duplicate arriving
+// after them is rejected.
+func WithTimestampPolicy(policy TimestampPolicy) Option {
+	return func(im *ImpulseMACD) error {
+		switch policy {
+		case TimestampAllow, TimestampReject:
+		case TimestampMerge:
+			if im.checkpointers() == nil {
+				return ErrCustomMovingAverage
+			}
+		default:
+			return fmt.Errorf("imacd: unknown timestamp policy %d", policy)
+		}
+		im.timestamps = policy
+		return nil
+	}
+}
+
+// updateTimed processes a bar, applying the timestamp policy when it is timed
+func (im *ImpulseMACD) updateTimed(bar PriceBar) (ImpulseValue, error) {
+	im.checkReentry()
+	if bar.Time.IsZero() || im.timestamps == TimestampAllow {
+		return im.update(bar.Time, bar.High, bar.Low, bar.Close, im.barSource(bar)), nil
+	}
+
+	if im.count > 0 && !im.lastTime.IsZero() {
+		switch {
+		case bar.Time.Before(im.lastTime):
+			return ImpulseValue{}, ErrOutOfOrder
+		case !bar.Time.Equal(im.lastTime):
+		case im.timestamps == TimestampReject:
+			return ImpulseValue{}, ErrDuplicateTimestamp
+		default:
+			n := len(im.checkpoints)
+			if n == 0 || !im.checkpoints[n-1].merge || !im.mergeBar.Time.Equal(bar.Time) {
+				return ImpulseValue{}, ErrDuplicateTimestamp
+			}
+			if err := im.popCheckpoint(); err != nil {
+				return ImpulseValue{}, err
+			}
+			prev := im.mergeBar
+			bar = PriceBar{
+				Open:   prev.Open,
+				High:   max(prev.High, bar.High),
+				Low:    min(prev.Low, bar.Low),
+				Close:  bar.Close,
+				Bid:    bar.Bid,
+				Ask:    bar.Ask,
+				Source: bar.Source,
+				Time:   bar.Time,
+			}
+		}
+	}
+
+	if im.timestamps == TimestampMerge {
+		im.dropMergePoint()
+		if err := im.pushCheckpoint(true); err != nil {
+			return ImpulseValue{}, err
+		}
+		im.mergeBar = bar
+	}
+	return im.update(bar.Time, bar.High, bar.Low, bar.Close, im.barSource(bar)), nil
+}
@@ -0,0 +1,123 @@
+package imacd
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+var tsStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newIndicator(t *testing.T, opts ...Option) *ImpulseMACD {
+	t.Helper()
+	im, err := NewImpulseMACDWithOptions(8, 5, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return im
+}
+
+func TestTimestampRepeatedAndBackwards(t *testing.T) {
+	tests := []struct {
+		policy    TimestampPolicy
+		repeated  error
+		backwards error
+	}{
+		{TimestampAllow, nil, ErrOutOfOrder},
+		{TimestampReject, ErrDuplicateTimestamp, ErrOutOfOrder},
+		{TimestampMerge, nil, ErrOutOfOrder},
+	}
+	for _, tt := range tests {
+		im := newIndicator(t, WithTimestampPolicy(tt.policy))
+		for i := range 3 {
+			if _, err := im.UpdateAt(tsStart.Add(time.Duration(i)*time.Minute), 11, 9, 10); err != nil {
+				t.Fatal(err)
+			}
+		}
+		last := tsStart.Add(2 * time.Minute)
+		if _, err := im.UpdateAt(last, 12, 10, 11); !errors.Is(err, tt.repeated) {
+			t.Errorf("policy %d: repeated timestamp error = %v, want %v", tt.policy, err, tt.repeated)
+		}
+		before := *im.GetLatest()
+		if _, err := im.UpdateAt(tsStart, 12, 10, 11); !errors.Is(err, tt.backwards) {
+			t.Errorf("policy %d: earlier timestamp error = %v, want %v", tt.policy, err, tt.backwards)
+		}
+		if *im.GetLatest() != before {
+			t.Errorf("policy %d: a rejected bar changed the latest value", tt.policy)
+		}
+	}
+}
+
+// TestTimestampBatch checks the batch methods: TimestampAllow processes
+// repeated and earlier bars, the other policies skip rejected ones with a
+// zero value
+func TestTimestampBatch(t *testing.T) {
+	bars := []PriceBar{
+		{High: 11, Low: 9, Close: 10, Time: tsStart.Add(time.Minute)},
+		{High: 12, Low: 10, Close: 11, Time: tsStart.Add(time.Minute)},
+		{High: 13, Low: 11, Close: 12, Time: tsStart},
+	}
+	allow := newIndicator(t).BatchUpdate(bars)
+	for i, v := range allow {
+		if v == (ImpulseValue{}) {
+			t.Errorf("TimestampAllow skipped bar %d", i)
+		}
+	}
+	reject := newIndicator(t, WithTimestampPolicy(TimestampReject)).BatchUpdate(bars)
+	if reject[0] == (ImpulseValue{}) || reject[1] != (ImpulseValue{}) || reject[2] != (ImpulseValue{}) {
+		t.Errorf("TimestampReject results = %+v, want the first bar only", reject)
+	}
+}
+
+// TestTimestampMerge checks that bars sharing a timestamp give the value of
+// the single aggregated bar, with and without history
+func TestTimestampMerge(t *testing.T) {
+	for _, name := range []string{"history", "no_history"} {
+		t.Run(name, func(t *testing.T) {
+			var opts []Option
+			if name == "no_history" {
+				opts = append(opts, WithNoHistory())
+			}
+			merged := newIndicator(t, append(opts, WithTimestampPolicy(TimestampMerge))...)
+			want := newIndicator(t, opts...)
+			for i := range 60 {
+				at := tsStart.Add(time.Duration(i) * time.Minute)
+				p := 100 + 5*math.Sin(float64(i)/4)
+				// Three ticks per bar, the first with the open
+				for _, tick := range [][3]float64{{p + 1, p - 1, p}, {p + 3, p, p + 2}, {p + 1, p - 2, p - 1}} {
+					if _, err := merged.UpdateAt(at, tick[0], tick[1], tick[2]); err != nil {
+						t.Fatalf("bar %d: %v", i, err)
+					}
+				}
+				exp, err := want.UpdateAt(at, p+3, p-2, p-1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := *merged.GetLatest(); got != exp {
+					t.Fatalf("bar %d: merged %+v, want %+v", i, got, exp)
+				}
+			}
+			if merged.count != want.count || len(merged.GetValues()) != len(want.GetValues()) {
+				t.Fatalf("merged %d bars with %d values, want %d with %d",
+					merged.count, len(merged.GetValues()), want.count, len(want.GetValues()))
+			}
+			if merged.LastCross() == CrossNone || merged.LastCross() != want.LastCross() {
+				t.Fatalf("LastCross %v, want %v", merged.LastCross(), want.LastCross())
+			}
+		})
+	}
+}
+
+func TestTimestampMergeWindowEnds(t *testing.T) {
+	im := newIndicator(t, WithTimestampPolicy(TimestampMerge))
+	if _, err := im.UpdateAt(tsStart, 11, 9, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := im.PushCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := im.UpdateAt(tsStart, 12, 10, 11); !errors.Is(err, ErrDuplicateTimestamp) {
+		t.Fatalf("duplicate after PushCheckpoint = %v, want ErrDuplicateTimestamp", err)
+	}
+}
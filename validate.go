@@ -0,0 +1,73 @@
+package imacd
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate inspects the sub-indicator state, including the histogram
+// smoothing, the z-score statistics and the min-max range, for NaN or
+// infinite values and returns an error naming the first offending field.
+// Indicators built from custom moving averages are checked through their
+// Value method only, and an RMA band is not checked before it is ready, as it
+// is NaN until then.
+func (im *ImpulseMACD) Validate() error {
+	type field struct {
+		name  string
+		value float64
+	}
+
+	var fields []field
+	if high, low, mid, sma, ok := im.builtins(); ok {
+		fields = []field{
+			{"smmaHigh.value", high.value},
+			{"smmaLow.value", low.value},
+			{"zlema.ema1.value", mid.ema1.value},
+			{"zlema.ema2.value", mid.ema2.value},
+			{"zlema.value", mid.value},
+			{"signalSMA.sum", sma.sum},
+			{"signalSMA.value", sma.value},
+		}
+		for i, v := range sma.window.All() {
+			fields = append(fields, field{fmt.Sprintf("signalSMA.values[%d]", i), v})
+		}
+	} else {
+		for _, ma := range []struct {
+			name string
+			ma   MovingAverage
+		}{
+			{"maHigh", im.maHigh},
+			{"maLow", im.maLow},
+			{"maMid", im.maMid},
+			{"maSignal", im.maSignal},
+		} {
+			if rma, ok := ma.ma.(*RMA); ok && !rma.IsReady() {
+				continue
+			}
+			fields = append(fields, field{ma.name, ma.ma.Value()})
+		}
+	}
+
+	if e := im.shSmoothing; e != nil {
+		fields = append(fields, field{"shSmoothing.value", e.value})
+	}
+	if r := im.shStats; r != nil {
+		fields = append(fields, field{"shStats.mean", r.mean}, field{"shStats.m2", r.m2})
+		for i, v := range r.values {
+			fields = append(fields, field{fmt.Sprintf("shStats.values[%d]", i), v})
+		}
+	}
+	if m := im.minMax; m != nil && m.seen {
+		fields = append(fields, field{"minMax.min", m.min}, field{"minMax.max", m.max})
+		for i, v := range m.values {
+			fields = append(fields, field{fmt.Sprintf("minMax.values[%d]", i), v})
+		}
+	}
+
+	for _, f := range fields {
+		if math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			return fmt.Errorf("imacd: invalid state: %s is %v", f.name, f.value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package imacd
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		corrupt func(*ImpulseMACD)
+		field   string
+	}{
+		{"band", nil, func(im *ImpulseMACD) { im.maHigh.(*SMMA).value = math.NaN() }, "smmaHigh.value"},
+		{"signal", nil, func(im *ImpulseMACD) { im.maSignal.(*SMA).sum = math.Inf(1) }, "signalSMA.sum"},
+		{"smoothing", []Option{WithHistogramSmoothing(3)}, func(im *ImpulseMACD) { im.shSmoothing.value = math.NaN() }, "shSmoothing.value"},
+		{"zscore_mean", []Option{WithSHZScore(4)}, func(im *ImpulseMACD) { im.shStats.mean = math.Inf(-1) }, "shStats.mean"},
+		{"zscore_window", []Option{WithSHZScore(4)}, func(im *ImpulseMACD) { im.shStats.values[1] = math.NaN() }, "shStats.values[1]"},
+		{"min_max", []Option{WithPersistentMinMax(0)}, func(im *ImpulseMACD) { im.minMax.max = math.Inf(1) }, "minMax.max"},
+		{"min_max_window", []Option{WithPersistentMinMax(5)}, func(im *ImpulseMACD) { im.minMax.values[0] = math.NaN() }, "minMax.values[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im := newIndicator(t, tt.opts...)
+			im.BatchUpdate(sineBars(10))
+			if err := im.Validate(); err != nil {
+				t.Fatalf("healthy indicator: %v", err)
+			}
+			tt.corrupt(im)
+			err := im.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.field+" is") {
+				t.Fatalf("Validate = %v, want an error naming %s", err, tt.field)
+			}
+		})
+	}
+}
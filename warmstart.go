@@ -0,0 +1,59 @@
+package imacd
+
+import "math"
+
+// WarmStart primes the indicator from only the most recent bars instead of a
+// full history replay. It resets the indicator, runs the bars through it
+// without calling OnCross or OnValue handlers or publishing to subscribers,
+// then discards the outputs: the history is empty afterwards while the state
+// and the bar count reflect the bars, so the next update continues as if the
+// bars had been streamed.
+//
+// The smoothing forgets old data geometrically, so the outputs converge to
+// those of a full replay; WarmStartBars gives the number of bars needed for a
+// tolerance.
+func (im *ImpulseMACD) WarmStart(recentBars []PriceBar) {
+	im.ResetState(false)
+
+	crossHandlers, valueHandlers := im.crosses.handlers, im.valueHandlers
+	im.crosses.handlers, im.valueHandlers = nil, nil
+	im.muted = true
+	defer func() {
+		im.crosses.handlers, im.valueHandlers = crossHandlers, valueHandlers
+		im.muted = false
+	}()
+	for _, bar := range recentBars {
+		im.updateBar(bar)
+	}
+
+	im.values = im.values[:0]
+	if im.retainSources {
+		im.sources = im.sources[:0]
+	}
+	if im.retainBands {
+		im.bands = im.bands[:0]
+	}
+	if im.retainInputs {
+		im.inputs = im.inputs[:0]
+	}
+}
+
+// WarmStartBars returns how many recent bars WarmStart needs for the outputs
+// to be within tolerance of a full replay, relative to how far the prices
+// before the window were from those in it. The slowest line is a band SMMA of
+// length L, which keeps a weight of ((L-1)/L)^n of anything older than n
+// bars, so n = ln(tolerance) / ln((L-1)/L), about L*ln(1/tolerance): roughly
+// 7L bars for 1e-3 and 14L for 1e-6. The signal window is added on top, as
+// the SMA only forgets a value once it leaves the window. Tolerances outside
+// (0, 1) are treated as 1e-6.
+func (im *ImpulseMACD) WarmStartBars(tolerance float64) int {
+	if !(tolerance > 0 && tolerance < 1) {
+		tolerance = 1e-6
+	}
+	length := max(im.lengthMA, im.bandLen(), 1)
+	decay := 0
+	if length > 1 {
+		decay = int(math.Ceil(math.Log(tolerance) / math.Log(float64(length-1)/float64(length))))
+	}
+	return decay + max(im.lengthSignal, 1) - 1
+}
@@ -0,0 +1,73 @@
+package imacd
+
+import "math"
+
+// MinBars returns the number of bars needed before the outputs are valid:
+// enough bars for the bands and mid line to cover their full lengths, then
+// enough MD values to fill the signal window, so a signal length far above
+// the bars seen never reads as valid from a partial average. Indicators built
+// from custom moving averages report 1.
+func (im *ImpulseMACD) MinBars() int {
+	if im.lengthMA < 1 || im.lengthSignal < 1 {
+		return 1
+	}
+	return minBars(im.lengthMA, im.lengthSignal, im.bandLen())
+}
+
+func minBars(lengthMA, lengthSignal, bandLength int) int {
+	return max(lengthMA, bandLength) + lengthSignal - 1
+}
+
+// IsWarmedUp reports whether enough bars have been processed for the outputs
+// to be valid. A custom signal average with an IsReady method, like the
+// built-in ones, must also report ready, so its partial window is not taken
+// for a signal.
+func (im *ImpulseMACD) IsWarmedUp() bool {
+	return im.count >= im.MinBars() && im.signalFull()
+}
+
+// signalFull reports whether the signal average has a full window; averages
+// without an IsReady method count as full
+func (im *ImpulseMACD) signalFull() bool {
+	r, ok := im.maSignal.(interface{ IsReady() bool })
+	return !ok || r.IsReady()
+}
+
+// JustWarmedUp reports whether the latest update was the one that completed
+// the warmup, so it is true for at most one bar of the stream. It counts bars
+// against MinBars, so it stays false for a custom signal average that only
+// becomes ready later.
+func (im *ImpulseMACD) JustWarmedUp() bool {
+	return im.count == im.MinBars() && im.signalFull()
+}
+
+// BarsUntilWarm returns how many more updates are needed before the outputs
+// are valid, or 0 once warmed up. While a custom signal average is not ready
+// it returns at least 1, as its window length is unknown.
+func (im *ImpulseMACD) BarsUntilWarm() int {
+	if remaining := im.MinBars() - im.count; remaining > 0 {
+		return remaining
+	}
+	if !im.signalFull() {
+		return 1
+	}
+	return 0
+}
+
+// Confidence returns a value in [0, 1] describing how far the SMMA bands have
+// converged away from their first-bar seed. After n bars the seed still
+// carries a weight of ((length-1)/length)^(n-1) for the band length, so the
+// confidence is one minus that weight: about 0.63 after length bars and 0.95
+// after three times length. It is 0 until the indicator is warmed up, and 1
+// once warm for indicators built from custom moving averages.
+func (im *ImpulseMACD) Confidence() float64 {
+	if !im.IsWarmedUp() {
+		return 0
+	}
+	length := im.bandLen()
+	if length < 1 {
+		return 1
+	}
+	decay := float64(length-1) / float64(length)
+	return 1 - math.Pow(decay, float64(im.count-1))
+}
@@ -0,0 +1,51 @@
+package imacd
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestSignalLongerThanHistory runs far fewer bars than the signal length and
+// checks that SB is the mean of the MD values so far while no value, nor the
+// indicator, reads as valid
+func TestSignalLongerThanHistory(t *testing.T) {
+	const lengthSignal = 50
+	bars := randomBars(rand.New(rand.NewPCG(11, 180)), 20)
+	for _, custom := range []bool{false, true} {
+		im := NewImpulseMACD(5, lengthSignal)
+		if custom {
+			// Custom averages report 1 from MinBars, so only the signal
+			// average's IsReady holds the warmup back
+			im = NewImpulseMACDWithMAs(NewSMMA(5), NewSMMA(5), NewZLEMA(5), NewSMA(lengthSignal))
+		}
+		sum := 0.0
+		for i, bar := range bars {
+			v := im.Update(bar.High, bar.Low, bar.Close)
+			sum += v.MD
+			if mean := sum / float64(i+1); math.Abs(v.SB-mean) > 1e-12*math.Max(1, math.Abs(mean)) {
+				t.Fatalf("custom=%t bar %d: SB %v, want the mean %v of the MD so far", custom, i, v.SB, mean)
+			}
+			if v.Valid || im.IsWarmedUp() || im.JustWarmedUp() {
+				t.Fatalf("custom=%t bar %d: valid from %d of %d signal values", custom, i, i+1, lengthSignal)
+			}
+			if im.BarsUntilWarm() < 1 {
+				t.Fatalf("custom=%t bar %d: BarsUntilWarm = %d, want at least 1", custom, i, im.BarsUntilWarm())
+			}
+		}
+		if !custom {
+			if want := 5 + lengthSignal - 1 - len(bars); im.BarsUntilWarm() != want {
+				t.Fatalf("BarsUntilWarm = %d, want %d", im.BarsUntilWarm(), want)
+			}
+		}
+	}
+
+	// Once the window fills, the value of that bar is the first valid one
+	im := NewImpulseMACD(5, lengthSignal)
+	for i, bar := range randomBars(rand.New(rand.NewPCG(12, 180)), im.MinBars()+1) {
+		v := im.Update(bar.High, bar.Low, bar.Close)
+		if want := i+1 >= im.MinBars(); v.Valid != want || im.IsWarmedUp() != want {
+			t.Fatalf("bar %d of MinBars %d: Valid %t, want %t", i+1, im.MinBars(), v.Valid, want)
+		}
+	}
+}